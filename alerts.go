@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Alert kinds, also used as the "event" field of webhook payloads.
+const (
+	alertCrash        = "miner_crashed"
+	alertRestart      = "miner_restarted"
+	alertLowHashrate  = "hashrate_low"
+	alertRejectSpike  = "rejects_spike"
+	alertTemperature  = "temperature_high"
+	alertPoolSwitched = "pool_switched"
+	alertBlockFound   = "block_found"
+	alertTest         = "test"
+)
+
+const (
+	// alertGraceMin skips hashrate alerts while the DAG is being built.
+	alertGraceMin = 2
+	// rejectWindow is the span the rejected-share rate is computed over, and
+	// rejectMinShares the shares needed in it before the rate means anything.
+	rejectWindow    = 10 * time.Minute
+	rejectMinShares = 5
+	// lowHashratePolls is how many polls in a row must be below the
+	// threshold, so a single slow poll doesn't alert.
+	lowHashratePolls = 3
+)
+
+// AlertsConfig holds the thresholds of the stat-based alerts; zero disables
+// an alert.
+type AlertsConfig struct {
+	MinMHs       float64 `json:"minMhs,omitempty"`
+	MaxRejectPct float64 `json:"maxRejectPct,omitempty"`
+	MaxTemp      int     `json:"maxTemp,omitempty"`
+}
+
+func (a AlertsConfig) Validate() error {
+	if a.MinMHs < 0 {
+		return fmt.Errorf("invalid hashrate threshold")
+	}
+	if a.MaxRejectPct < 0 || a.MaxRejectPct > 100 {
+		return fmt.Errorf("invalid rejected share threshold (0..100 %%)")
+	}
+	if a.MaxTemp < 0 || a.MaxTemp > 120 {
+		return fmt.Errorf("invalid temperature limit (0..120 °C)")
+	}
+	return nil
+}
+
+// alertEvent is something worth telling the operator about.
+type alertEvent struct {
+	Kind    string
+	Time    time.Time
+	Message string
+	Details map[string]any
+}
+
+type shareSample struct {
+	t                  time.Time
+	accepted, rejected int64
+}
+
+// alertMonitor turns polled stats into threshold alerts. Each alert fires
+// once when its condition starts and re-arms when it clears.
+type alertMonitor struct {
+	mu  sync.Mutex
+	cfg AlertsConfig
+
+	lowPolls  int
+	lowActive bool
+
+	shares       []shareSample
+	rejectActive bool
+
+	hotActive map[int]bool
+
+	lastAccepted int64
+	hasAccepted  bool
+}
+
+func newAlertMonitor(cfg AlertsConfig) *alertMonitor {
+	return &alertMonitor{cfg: cfg, hotActive: map[int]bool{}}
+}
+
+// SetConfig changes the thresholds; conditions already alerted on stay
+// active until they clear.
+func (m *alertMonitor) SetConfig(cfg AlertsConfig) {
+	m.mu.Lock()
+	m.cfg = cfg
+	m.mu.Unlock()
+}
+
+// Reset forgets the previous run, e.g. when mining starts again.
+func (m *alertMonitor) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lowPolls, m.lowActive = 0, false
+	m.shares, m.rejectActive = nil, false
+	m.hotActive = map[int]bool{}
+	m.lastAccepted, m.hasAccepted = 0, false
+}
+
+// Check evaluates one aggregated poll taken at now. In solo mode every
+// accepted solution is a block.
+func (m *alertMonitor) Check(now time.Time, s Stat, solo bool) []alertEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var res []alertEvent
+	fire := func(kind, msg string, details map[string]any) {
+		res = append(res, alertEvent{Kind: kind, Time: now, Message: msg, Details: details})
+	}
+
+	mhs := float64(s.TotalKHs) / 1000
+	if m.cfg.MinMHs > 0 && s.UptimeMin >= alertGraceMin && mhs < m.cfg.MinMHs {
+		m.lowPolls++
+		if m.lowPolls >= lowHashratePolls && !m.lowActive {
+			m.lowActive = true
+			fire(alertLowHashrate, fmt.Sprintf("Hashrate dropped to %.2f MH/s (threshold %.2f MH/s)", mhs, m.cfg.MinMHs),
+				map[string]any{"mhs": mhs, "thresholdMhs": m.cfg.MinMHs})
+		}
+	} else {
+		m.lowPolls = 0
+		m.lowActive = false
+	}
+
+	// Share counters restart with the miner; start the window over.
+	if n := len(m.shares); n > 0 && (s.Accepted < m.shares[n-1].accepted || s.Rejected < m.shares[n-1].rejected) {
+		m.shares = nil
+	}
+	m.shares = append(m.shares, shareSample{t: now, accepted: s.Accepted, rejected: s.Rejected})
+	for len(m.shares) > 1 && now.Sub(m.shares[1].t) >= rejectWindow {
+		m.shares = m.shares[1:]
+	}
+	if m.cfg.MaxRejectPct > 0 {
+		first := m.shares[0]
+		acc, rej := s.Accepted-first.accepted, s.Rejected-first.rejected
+		pct := 0.0
+		if acc+rej > 0 {
+			pct = float64(rej) * 100 / float64(acc+rej)
+		}
+		switch {
+		case acc+rej >= rejectMinShares && pct >= m.cfg.MaxRejectPct:
+			if !m.rejectActive {
+				m.rejectActive = true
+				fire(alertRejectSpike, fmt.Sprintf("%.0f%% of shares rejected in the last %d minutes (%d of %d)", pct, int(rejectWindow/time.Minute), rej, acc+rej),
+					map[string]any{"rejectPct": pct, "rejected": rej, "shares": acc + rej, "thresholdPct": m.cfg.MaxRejectPct})
+			}
+		case pct < m.cfg.MaxRejectPct:
+			m.rejectActive = false
+		}
+	}
+
+	if solo && m.hasAccepted && s.Accepted > m.lastAccepted {
+		msg := "Block found"
+		if n := s.Accepted - m.lastAccepted; n > 1 {
+			msg = fmt.Sprintf("%d blocks found", n)
+		}
+		fire(alertBlockFound, fmt.Sprintf("%s (%d so far this run)", msg, s.Accepted),
+			map[string]any{"blocks": s.Accepted - m.lastAccepted, "totalBlocks": s.Accepted})
+	}
+	m.lastAccepted, m.hasAccepted = s.Accepted, true
+
+	if m.cfg.MaxTemp > 0 {
+		for i, t := range s.Temps {
+			if t >= m.cfg.MaxTemp {
+				if !m.hotActive[i] {
+					m.hotActive[i] = true
+					fire(alertTemperature, fmt.Sprintf("GPU %d at %d°C (limit %d°C)", i, t, m.cfg.MaxTemp),
+						map[string]any{"gpu": i, "temp": t, "limit": m.cfg.MaxTemp})
+				}
+			} else if t < m.cfg.MaxTemp-3 {
+				// A few degrees of hysteresis so a card hovering at the
+				// limit doesn't alert every poll.
+				m.hotActive[i] = false
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rpcMethodNotFound = -32601
+
+	apiDialTimeout = 1 * time.Second
+	apiCallTimeout = 1500 * time.Millisecond
+	maxAPIBackoff  = 30 * time.Second
+
+	defaultPollInterval = 2
+)
+
+type apiResp struct {
+	ID     int64           `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("api error %d: %s", e.Code, e.Message)
+}
+
+// apiClient talks JSON-RPC to one ethminer API endpoint over a single
+// persistent connection. It dials lazily, authorizes the connection when a
+// password is set, and drops it on any I/O error so the next call
+// reconnects. Calls are serialized.
+type apiClient struct {
+	addr     string
+	password string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+	nextID int64
+	// detail is cleared once the miner reports miner_getstatdetail as
+	// unknown, so later polls go straight to miner_getstat1.
+	detail bool
+}
+
+func newAPIClient(host string, port int, password string) *apiClient {
+	return &apiClient{
+		addr:     net.JoinHostPort(host, strconv.Itoa(port)),
+		password: password,
+		detail:   true,
+	}
+}
+
+func (c *apiClient) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+func (c *apiClient) closeLocked() {
+	if c.conn != nil {
+		_ = c.conn.Close()
+		c.conn, c.reader = nil, nil
+	}
+}
+
+// Call sends one request and waits for its response. params may be nil.
+// API-level errors are returned as *rpcError and keep the connection open.
+func (c *apiClient) Call(method string, params any) (json.RawMessage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		conn, err := net.DialTimeout("tcp", c.addr, apiDialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		c.conn, c.reader = conn, bufio.NewReader(conn)
+		// ethminer authorizes per connection, so a reconnect has to log in again.
+		if c.password != "" {
+			if _, err := c.roundTripLocked("api_authorize", map[string]string{"psw": c.password}); err != nil {
+				c.closeLocked()
+				return nil, fmt.Errorf("api authorization failed: %w", err)
+			}
+		}
+	}
+	return c.roundTripLocked(method, params)
+}
+
+func (c *apiClient) roundTripLocked(method string, params any) (json.RawMessage, error) {
+	c.nextID++
+	req := map[string]any{"id": c.nextID, "jsonrpc": "2.0", "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	b, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = c.conn.SetDeadline(time.Now().Add(apiCallTimeout))
+	if _, err := c.conn.Write(append(b, '\n')); err != nil {
+		c.closeLocked()
+		return nil, err
+	}
+	line, err := c.reader.ReadBytes('\n')
+	if err != nil {
+		c.closeLocked()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("api connection closed by miner")
+		}
+		return nil, err
+	}
+
+	var resp apiResp
+	if err := json.Unmarshal(line, &resp); err != nil {
+		c.closeLocked()
+		return nil, err
+	}
+	// A reply to an earlier, timed-out request means the stream is out of
+	// sync; start over on a fresh connection.
+	if resp.ID != 0 && resp.ID != c.nextID {
+		c.closeLocked()
+		return nil, fmt.Errorf("api response id %d does not match request %d", resp.ID, c.nextID)
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	return resp.Result, nil
+}
+
+// Stat prefers miner_getstatdetail and falls back to miner_getstat1 for
+// builds that don't implement it.
+func (c *apiClient) Stat() (Stat, error) {
+	c.mu.Lock()
+	detail := c.detail
+	c.mu.Unlock()
+	if detail {
+		st, err := c.getStatDetail()
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) || rpcErr.Code != rpcMethodNotFound {
+			return st, err
+		}
+		c.mu.Lock()
+		c.detail = false
+		c.mu.Unlock()
+	}
+	return c.getStat1()
+}
+
+// pollStats polls the miner every interval. While the API is unreachable the
+// delay backs off exponentially up to maxAPIBackoff. onErr is only called when
+// the error changes, so an outage logs once; onDown receives the time the
+// outage began on every failed poll and a zero time once the API answers again.
+func pollStats(ctx context.Context, client *apiClient, interval time.Duration, onStat func(Stat), onErr func(error), onDown func(since time.Time)) {
+	defer client.Close()
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	var (
+		downSince time.Time
+		lastErr   string
+		delay     time.Duration
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		st, err := client.Stat()
+		if err != nil {
+			if downSince.IsZero() {
+				downSince = time.Now()
+			}
+			if msg := err.Error(); msg != lastErr {
+				lastErr = msg
+				onErr(err)
+			}
+			onDown(downSince)
+			delay = nextPollBackoff(delay, interval)
+			timer.Reset(delay)
+			continue
+		}
+		if !downSince.IsZero() {
+			downSince, lastErr, delay = time.Time{}, "", 0
+			onDown(time.Time{})
+		}
+		onStat(st)
+		timer.Reset(interval)
+	}
+}
+
+func nextPollBackoff(cur, interval time.Duration) time.Duration {
+	if cur < interval {
+		return interval
+	}
+	cur *= 2
+	if cur > maxAPIBackoff {
+		cur = maxAPIBackoff
+	}
+	return cur
+}
+
+func (c *apiClient) getStat1() (Stat, error) {
+	result, err := c.Call("miner_getstat1", nil)
+	if err != nil {
+		return Stat{}, err
+	}
+	var arr []string
+	if err := json.Unmarshal(result, &arr); err != nil {
+		return Stat{}, err
+	}
+	if len(arr) < 9 {
+		return Stat{}, fmt.Errorf("unexpected stat format (%d items)", len(arr))
+	}
+
+	st := Stat{Version: arr[0]}
+	st.UptimeMin, _ = strconv.Atoi(arr[1])
+
+	// "kh;accepted;rejected"
+	if parts := strings.Split(arr[2], ";"); len(parts) >= 3 {
+		st.TotalKHs, _ = strconv.ParseInt(parts[0], 10, 64)
+		st.Accepted, _ = strconv.ParseInt(parts[1], 10, 64)
+		st.Rejected, _ = strconv.ParseInt(parts[2], 10, 64)
+	}
+
+	// "kh1;kh2;..."
+	if parts := strings.Split(arr[3], ";"); len(parts) > 0 && parts[0] != "" {
+		for _, p := range parts {
+			v, _ := strconv.ParseInt(p, 10, 64)
+			st.PerGPU_KHs = append(st.PerGPU_KHs, v)
+		}
+	}
+
+	// temps/fans pairs
+	if parts := strings.Split(arr[6], ";"); len(parts) >= 2 {
+		for i := 0; i+1 < len(parts); i += 2 {
+			t, _ := strconv.Atoi(parts[i])
+			f, _ := strconv.Atoi(parts[i+1])
+			st.Temps = append(st.Temps, t)
+			st.Fans = append(st.Fans, f)
+		}
+	}
+
+	st.Pool = arr[7]
+	st.Connected = st.Pool != ""
+
+	// "ethInvalid;ethSwitches;dcrInvalid;dcrSwitches"
+	if parts := strings.Split(arr[8], ";"); len(parts) >= 2 {
+		st.Invalid, _ = strconv.ParseInt(parts[0], 10, 64)
+		st.PoolSwitches, _ = strconv.ParseInt(parts[1], 10, 64)
+	}
+
+	// getstat1 has no per-device shares or names; fill what it does report.
+	for i, kh := range st.PerGPU_KHs {
+		d := DeviceStat{Index: i, KHs: kh}
+		if i < len(st.Temps) {
+			d.Temp = st.Temps[i]
+			d.Fan = st.Fans[i]
+		}
+		st.Devices = append(st.Devices, d)
+	}
+	return st, nil
+}
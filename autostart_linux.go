@@ -0,0 +1,99 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const autostartFileName = "olivetum-miner-gui.desktop"
+
+func autostartSupported() bool { return true }
+
+func autostartPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "autostart", autostartFileName), nil
+}
+
+// autostartExecPath returns the command to launch on login. Inside an AppImage
+// the executable lives on a temporary mount, so the AppImage file itself is
+// used instead.
+func autostartExecPath() (string, error) {
+	if p := strings.TrimSpace(os.Getenv("APPIMAGE")); p != "" {
+		return p, nil
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return exe, nil
+}
+
+func autostartInstalled() bool {
+	path, err := autostartPath()
+	if err != nil {
+		return false
+	}
+	st, err := os.Stat(path)
+	return err == nil && !st.IsDir()
+}
+
+func setAutostart(enabled bool) error {
+	path, err := autostartPath()
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	exe, err := autostartExecPath()
+	if err != nil {
+		return fmt.Errorf("cannot resolve executable: %w", err)
+	}
+	entry := strings.Join([]string{
+		"[Desktop Entry]",
+		"Type=Application",
+		"Name=" + appName,
+		"Comment=Start " + appName + " on login",
+		"Exec=" + desktopExecQuote(exe),
+		"Icon=olivetum-miner-gui",
+		"Terminal=false",
+		"X-GNOME-Autostart-enabled=true",
+		"",
+	}, "\n")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(entry), 0o644)
+}
+
+// desktopExecQuote quotes an argument per the Desktop Entry spec, including
+// the %% escape for field codes.
+func desktopExecQuote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"', '`', '$', '\\':
+			b.WriteByte('\\')
+		case '%':
+			b.WriteByte('%')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
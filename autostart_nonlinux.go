@@ -0,0 +1,13 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+func autostartSupported() bool { return false }
+
+func autostartInstalled() bool { return false }
+
+func setAutostart(_ bool) error {
+	return errors.New("launch on login is only supported on Linux")
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const probeTimeout = 10 * time.Second
+
+// EthminerBuild is an ethminer binary registered in the config. Version and
+// Olivetum are cached from the last probe and only used for display.
+type EthminerBuild struct {
+	Path     string `json:"path"`
+	Version  string `json:"version,omitempty"`
+	Olivetum bool   `json:"olivetum"`
+}
+
+type ethminerInfo struct {
+	Path      string
+	Version   string
+	Olivetum  bool
+	SHA256    string
+	Integrity integrityStatus
+}
+
+func (i ethminerInfo) String() string {
+	v := i.Version
+	if v == "" {
+		v = "unknown version"
+	}
+	if i.Olivetum {
+		return v + " (Olivetum)"
+	}
+	return v
+}
+
+var ethminerVersionRe = regexp.MustCompile(`\d+\.\d+\.\d+[0-9A-Za-z.+\-]*`)
+
+func runProbe(path string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), probeTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, args...)
+	configureChildProcess(cmd)
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	out, err := cmd.CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return string(out), fmt.Errorf("%s %s timed out", filepath.Base(path), strings.Join(args, " "))
+	}
+	return string(out), err
+}
+
+// probeEthminer checks that path is a runnable ethminer and detects its
+// version and whether it supports --olivetum.
+func probeEthminer(path string) (ethminerInfo, error) {
+	info := ethminerInfo{Path: path}
+	st, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return info, fmt.Errorf("ethminer binary is missing: %s", path)
+		}
+		return info, fmt.Errorf("cannot access ethminer binary %s: %w", path, err)
+	}
+	if st.IsDir() {
+		return info, fmt.Errorf("ethminer path is a directory: %s", path)
+	}
+
+	out, err := runProbe(path, "--version")
+	if err != nil && strings.TrimSpace(out) == "" {
+		return info, fmt.Errorf("cannot run %s --version: %w", path, err)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if v := ethminerVersionRe.FindString(line); v != "" {
+			info.Version = v
+		} else {
+			info.Version = line
+		}
+		break
+	}
+	if strings.Contains(strings.ToLower(out), "olivetum") {
+		info.Olivetum = true
+		return info, nil
+	}
+
+	help, _ := runProbe(path, "--help")
+	info.Olivetum = strings.Contains(strings.ToLower(help), "--olivetum")
+	return info, nil
+}
+
+// resolveEthminer picks the configured binary, or auto-detects one when no
+// path is set. The binary is only executed after its digest is verified, and
+// builds without Olivetumhash support are rejected.
+func resolveEthminer(cfg *Config, trusted []TrustedBinary) (ethminerInfo, error) {
+	path := strings.TrimSpace(cfg.EthminerPath)
+	if path == "" {
+		found, err := findEthminer()
+		if err != nil {
+			return ethminerInfo{}, errors.New("ethminer not found next to this app or in PATH")
+		}
+		path = found
+	}
+	sum, status, err := verifyEthminer(path, trusted)
+	if err != nil {
+		return ethminerInfo{Path: path, SHA256: sum}, err
+	}
+	info, err := probeEthminer(path)
+	info.SHA256, info.Integrity = sum, status
+	if err != nil {
+		return info, err
+	}
+	return info, info.compatible()
+}
+
+func (i ethminerInfo) compatible() error {
+	if !i.Olivetum {
+		return fmt.Errorf("incompatible ethminer %s at %s: --olivetum is not supported; use an Olivetum build", i.String(), i.Path)
+	}
+	return nil
+}
+
+// registerBuild adds or refreshes a build in the list, keyed by path.
+func registerBuild(builds []EthminerBuild, info ethminerInfo) []EthminerBuild {
+	b := EthminerBuild{Path: info.Path, Version: info.Version, Olivetum: info.Olivetum}
+	for i := range builds {
+		if builds[i].Path == info.Path {
+			builds[i] = b
+			return builds
+		}
+	}
+	return append(builds, b)
+}
+
+func removeBuild(builds []EthminerBuild, path string) []EthminerBuild {
+	res := builds[:0]
+	for _, b := range builds {
+		if b.Path != path {
+			res = append(res, b)
+		}
+	}
+	return res
+}
+
+func buildLabel(b EthminerBuild) string {
+	v := b.Version
+	if v == "" {
+		v = "unknown"
+	}
+	return fmt.Sprintf("%s — %s", v, b.Path)
+}
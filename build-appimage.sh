@@ -28,8 +28,10 @@ fi
 
 echo "[1/4] Building GUI..."
 cd "${ROOT_DIR}"
+# Pin the bundled ethminer so the GUI can verify it before running it.
+ETHMINER_SHA256="$(sha256sum "${ETHMINER_SRC}" | cut -d' ' -f1)"
 go mod tidy
-go build -trimpath -ldflags="-s -w" -o "${DIST_DIR}/olivetum-miner-gui" ./...
+go build -trimpath -ldflags="-s -w -X main.bundledEthminerSHA256=${ETHMINER_SHA256}" -o "${DIST_DIR}/olivetum-miner-gui" ./...
 
 echo "[2/4] Building AppDir..."
 rm -rf "${APPDIR}"
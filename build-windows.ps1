@@ -1,5 +1,8 @@
 Param(
-  [string]$EthminerSrc = ""
+  [string]$EthminerSrc = "",
+  # Digest of the ethminer.exe users get alongside the GUI when it is not
+  # bundled here. Taken from -EthminerSrc otherwise.
+  [string]$EthminerSHA256 = ""
 )
 
 $ErrorActionPreference = "Stop"
@@ -9,16 +12,34 @@ $Dist = Join-Path $Root "dist"
 
 New-Item -ItemType Directory -Force -Path $Dist | Out-Null
 
+if ($EthminerSrc -ne "") {
+  if (!(Test-Path $EthminerSrc)) {
+    throw "ethminer binary not found at: $EthminerSrc"
+  }
+  $Sum = (Get-FileHash -Algorithm SHA256 $EthminerSrc).Hash.ToLower()
+  if ($EthminerSHA256 -ne "" -and $EthminerSHA256.ToLower() -ne $Sum) {
+    throw "ethminer SHA-256 is $Sum, expected $EthminerSHA256"
+  }
+  $EthminerSHA256 = $Sum
+}
+if ($EthminerSHA256 -ne "" -and $EthminerSHA256 -notmatch '^[0-9a-fA-F]{64}$') {
+  throw "invalid ethminer SHA-256: $EthminerSHA256"
+}
+
 Write-Host "[1/3] Building GUI..."
+# Pin the bundled ethminer so the GUI can verify it before running it.
+$LdFlags = "-H=windowsgui -s -w"
+if ($EthminerSHA256 -ne "") {
+  $LdFlags += " -X main.bundledEthminerSHA256=$($EthminerSHA256.ToLower())"
+} else {
+  Write-Warning "No ethminer digest pinned; the GUI will ask users to trust ethminer.exe."
+}
 Push-Location $Root
 go mod tidy
-go build -trimpath -ldflags="-H=windowsgui -s -w" -o (Join-Path $Dist "OlivetumMiner.exe") .
+go build -trimpath -ldflags="$LdFlags" -o (Join-Path $Dist "OlivetumMiner.exe") .
 Pop-Location
 
 if ($EthminerSrc -ne "") {
-  if (!(Test-Path $EthminerSrc)) {
-    throw "ethminer binary not found at: $EthminerSrc"
-  }
   Write-Host "[2/3] Copying ethminer..."
   Copy-Item -Force $EthminerSrc (Join-Path $Dist "ethminer.exe")
 } else {
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	minVerbosity     = 0
+	maxVerbosity     = 9
+	defaultVerbosity = 2
+)
+
+// poolConnection is one entry of miner_getconnections.
+type poolConnection struct {
+	Index  int    `json:"index"`
+	Active bool   `json:"active"`
+	URI    string `json:"uri"`
+}
+
+// newAPIPassword returns a random password for one miner session. The API is
+// bound read-write, so every process gets its own secret.
+func newAPIPassword() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// callOK runs a control method that answers with a boolean result.
+func (c *apiClient) callOK(method string, params any) error {
+	result, err := c.Call(method, params)
+	if err != nil {
+		return err
+	}
+	var ok bool
+	if err := json.Unmarshal(result, &ok); err == nil && !ok {
+		return fmt.Errorf("%s was refused by the miner", method)
+	}
+	return nil
+}
+
+// Restart restarts mining inside the running process (new DAG, reconnect).
+func (c *apiClient) Restart() error {
+	return c.callOK("miner_restart", nil)
+}
+
+// Reboot asks ethminer to run the reboot script next to its binary.
+func (c *apiClient) Reboot() error {
+	return c.callOK("miner_reboot", nil)
+}
+
+// PauseGPU pauses or resumes one device. index is the miner's own device
+// index, not the system-wide one.
+func (c *apiClient) PauseGPU(index int, pause bool) error {
+	return c.callOK("miner_pausegpu", map[string]any{"index": index, "pause": pause})
+}
+
+func (c *apiClient) SetVerbosity(level int) error {
+	if level < minVerbosity || level > maxVerbosity {
+		return fmt.Errorf("invalid verbosity %d (%d..%d)", level, minVerbosity, maxVerbosity)
+	}
+	return c.callOK("miner_setverbosity", map[string]any{"verbosity": level})
+}
+
+func (c *apiClient) Connections() ([]poolConnection, error) {
+	result, err := c.Call("miner_getconnections", nil)
+	if err != nil {
+		return nil, err
+	}
+	var conns []poolConnection
+	if err := json.Unmarshal(result, &conns); err != nil {
+		return nil, err
+	}
+	return conns, nil
+}
+
+func (c *apiClient) SetActiveConnection(index int) error {
+	return c.callOK("miner_setactiveconnection", map[string]any{"index": index})
+}
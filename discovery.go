@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	discoveryApp      = "olivetum-miner-gui"
+	discoveryPort     = 47474
+	defaultLANPort    = 47475
+	announceInterval  = 10 * time.Second
+	discoveredTimeout = 3 * announceInterval
+)
+
+// announcement is broadcast by rigs that opted in to LAN sharing. It must
+// never carry secrets: peers learn only where to poll read-only stats.
+type announcement struct {
+	App     string `json:"app"`
+	ID      string `json:"id"`
+	Rig     string `json:"rig"`
+	APIPort int    `json:"apiPort"`
+	Version string `json:"version,omitempty"`
+}
+
+// discoveredRig is an announcing rig seen on the network.
+type discoveredRig struct {
+	announcement
+	Host     string
+	LastSeen time.Time
+}
+
+func (d discoveredRig) addr() string {
+	return net.JoinHostPort(d.Host, strconv.Itoa(d.APIPort))
+}
+
+// newInstanceID identifies this process so it can ignore its own
+// announcements.
+func newInstanceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func defaultRigName() string {
+	if h, err := os.Hostname(); err == nil && h != "" {
+		return h
+	}
+	return "rig"
+}
+
+// announce sends msg to target every interval until ctx is done. target is
+// the broadcast address in production and a loopback address in tests.
+func announce(ctx context.Context, target string, interval time.Duration, msg func() announcement) error {
+	dst, err := net.ResolveUDPAddr("udp4", target)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenPacket("udp4", ":0")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m := msg()
+		m.App = discoveryApp
+		if b, err := json.Marshal(m); err == nil {
+			_, _ = conn.WriteTo(b, dst)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// discoveryListener collects announcements from other instances.
+type discoveryListener struct {
+	selfID string
+	now    func() time.Time
+
+	mu    sync.Mutex
+	peers map[string]discoveredRig
+}
+
+func newDiscoveryListener(selfID string) *discoveryListener {
+	return &discoveryListener{selfID: selfID, now: time.Now, peers: map[string]discoveredRig{}}
+}
+
+// Serve receives announcements on conn until ctx is done or conn is closed,
+// calling onChange whenever a rig appears or changes. It closes conn.
+func (l *discoveryListener) Serve(ctx context.Context, conn net.PacketConn, onChange func()) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var a announcement
+		if json.Unmarshal(buf[:n], &a) != nil || a.App != discoveryApp || a.ID == l.selfID {
+			continue
+		}
+		if a.APIPort < 1 || a.APIPort > 65535 || a.Rig == "" {
+			continue
+		}
+		udp, ok := from.(*net.UDPAddr)
+		if !ok {
+			continue
+		}
+		rig := discoveredRig{announcement: a, Host: udp.IP.String(), LastSeen: l.now()}
+		l.mu.Lock()
+		prev, seen := l.peers[a.ID]
+		l.peers[a.ID] = rig
+		l.mu.Unlock()
+		if !seen || prev.announcement != a || prev.Host != rig.Host {
+			onChange()
+		}
+	}
+}
+
+// Peers returns rigs heard from recently, sorted by name.
+func (l *discoveryListener) Peers() []discoveredRig {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	var res []discoveredRig
+	for id, p := range l.peers {
+		if now.Sub(p.LastSeen) > discoveredTimeout {
+			delete(l.peers, id)
+			continue
+		}
+		res = append(res, p)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Rig < res[j].Rig })
+	return res
+}
+
+// Clear forgets all rigs, e.g. when discovery is turned off.
+func (l *discoveryListener) Clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clear(l.peers)
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDiscoveryLoopback(t *testing.T) {
+	conn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newDiscoveryListener("self")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 16)
+	served := make(chan error, 1)
+	go func() {
+		served <- l.Serve(ctx, conn, func() { changes <- struct{}{} })
+	}()
+	target := conn.LocalAddr().String()
+
+	// Junk and incomplete announcements are ignored.
+	raw, err := net.Dial("udp4", target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, msg := range []string{
+		`not json`,
+		`{"app":"something-else","id":"a","rig":"a","apiPort":1}`,
+		`{"app":"olivetum-miner-gui","id":"b","rig":"","apiPort":1}`,
+		`{"app":"olivetum-miner-gui","id":"c","rig":"c","apiPort":0}`,
+	} {
+		if _, err := raw.Write([]byte(msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	raw.Close()
+
+	actx, stopAnnouncing := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	for _, a := range []announcement{
+		{ID: "self", Rig: "this rig", APIPort: 1},
+		{ID: "peer", Rig: "rig2", APIPort: defaultLANPort, Version: "0.19.0"},
+	} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := announce(actx, target, 20*time.Millisecond, func() announcement { return a }); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no announcement received")
+	}
+	// Let both rigs announce a few more times; repeats are not changes.
+	time.Sleep(150 * time.Millisecond)
+	stopAnnouncing()
+	wg.Wait()
+	cancel()
+	if err := <-served; err != nil {
+		t.Fatalf("Serve: %v", err)
+	}
+	if n := len(changes); n != 0 {
+		t.Errorf("%d more change notifications, want none", n)
+	}
+
+	peers := l.Peers()
+	if len(peers) != 1 {
+		t.Fatalf("peers = %+v, want only rig2", peers)
+	}
+	p := peers[0]
+	if p.ID != "peer" || p.Rig != "rig2" || p.Version != "0.19.0" || p.App != discoveryApp {
+		t.Errorf("peer = %+v", p)
+	}
+	if got, want := p.addr(), net.JoinHostPort("127.0.0.1", "47475"); got != want {
+		t.Errorf("addr = %s, want %s", got, want)
+	}
+
+	// Rigs that stop announcing expire.
+	seen := p.LastSeen
+	l.now = func() time.Time { return seen.Add(discoveredTimeout) }
+	if n := len(l.Peers()); n != 1 {
+		t.Errorf("%d peers at the timeout, want 1", n)
+	}
+	l.now = func() time.Time { return seen.Add(discoveredTimeout + time.Second) }
+	if n := len(l.Peers()); n != 0 {
+		t.Errorf("%d peers after the timeout, want 0", n)
+	}
+}
+
+func TestDiscoveryClear(t *testing.T) {
+	l := newDiscoveryListener("self")
+	l.peers["peer"] = discoveredRig{announcement: announcement{ID: "peer", Rig: "rig2", APIPort: 1}, LastSeen: l.now()}
+	l.Clear()
+	if n := len(l.Peers()); n != 0 {
+		t.Errorf("%d peers after Clear, want 0", n)
+	}
+}
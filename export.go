@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	exportCSV   = "csv"
+	exportJSONL = "jsonl"
+
+	// exportLive selects the in-memory chart samples instead of a history
+	// resolution.
+	exportLive = -1
+)
+
+// exportRow is one exported sample. Live samples only carry the total
+// hashrate; history rows carry everything historyPoint holds.
+type exportRow struct {
+	Time          time.Time `json:"time"`
+	Resolution    string    `json:"resolution"`
+	Samples       int       `json:"samples"`
+	MHs           float64   `json:"mhs"`
+	PeakMHs       float64   `json:"peakMhs"`
+	GPUMHs        []float64 `json:"gpuMhs,omitempty"`
+	Temps         []float64 `json:"temps,omitempty"`
+	Accepted      int64     `json:"accepted"`
+	Rejected      int64     `json:"rejected"`
+	Invalid       int64     `json:"invalid"`
+	SessionStarts int       `json:"sessionStarts"`
+	SessionEnds   int       `json:"sessionEnds"`
+}
+
+func exportRowsFromHistory(res string, pts []historyPoint) []exportRow {
+	rows := make([]exportRow, 0, len(pts))
+	for _, p := range pts {
+		r := exportRow{
+			Time:          p.T,
+			Resolution:    res,
+			Samples:       p.N,
+			MHs:           p.KHs / 1000,
+			PeakMHs:       float64(p.MaxKHs) / 1000,
+			Temps:         p.Temps,
+			Accepted:      p.Accepted,
+			Rejected:      p.Rejected,
+			Invalid:       p.Invalid,
+			SessionStarts: p.Starts,
+			SessionEnds:   p.Ends,
+		}
+		for _, kh := range p.GPUKHs {
+			r.GPUMHs = append(r.GPUMHs, kh/1000)
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+func exportRowsFromChart(times []time.Time, mhs []float64, from, to time.Time) []exportRow {
+	var rows []exportRow
+	for i, t := range times {
+		if t.Before(from) || !t.Before(to) {
+			continue
+		}
+		rows = append(rows, exportRow{Time: t.UTC(), Resolution: "live", Samples: 1, MHs: mhs[i], PeakMHs: mhs[i]})
+	}
+	return rows
+}
+
+// autoExportResolution picks the finest history resolution still retained
+// at from.
+func autoExportResolution(from, now time.Time) int {
+	for i, res := range historyResolutions {
+		if !from.Before(now.Add(-res.Keep)) {
+			return i
+		}
+	}
+	return len(historyResolutions) - 1
+}
+
+func writeExport(w io.Writer, format string, rows []exportRow) error {
+	switch format {
+	case exportCSV:
+		return writeExportCSV(w, rows)
+	case exportJSONL:
+		enc := json.NewEncoder(w)
+		for _, r := range rows {
+			if err := enc.Encode(r); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown export format %q", format)
+	}
+}
+
+// writeExportCSV writes one row per sample with a column per GPU; GPUs a
+// row has no data for are left empty.
+func writeExportCSV(w io.Writer, rows []exportRow) error {
+	gpus := 0
+	for _, r := range rows {
+		gpus = max(gpus, len(r.GPUMHs), len(r.Temps))
+	}
+	header := []string{"time", "resolution", "samples", "hashrate_mhs", "peak_mhs",
+		"accepted", "rejected", "invalid", "session_starts", "session_ends"}
+	for i := 0; i < gpus; i++ {
+		header = append(header, fmt.Sprintf("gpu%d_mhs", i))
+	}
+	for i := 0; i < gpus; i++ {
+		header = append(header, fmt.Sprintf("gpu%d_temp", i))
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	column := func(vals []float64, i int) string {
+		if i < len(vals) {
+			return num(vals[i])
+		}
+		return ""
+	}
+	for _, r := range rows {
+		rec := []string{
+			r.Time.UTC().Format(time.RFC3339),
+			r.Resolution,
+			strconv.Itoa(r.Samples),
+			num(r.MHs),
+			num(r.PeakMHs),
+			strconv.FormatInt(r.Accepted, 10),
+			strconv.FormatInt(r.Rejected, 10),
+			strconv.FormatInt(r.Invalid, 10),
+			strconv.Itoa(r.SessionStarts),
+			strconv.Itoa(r.SessionEnds),
+		}
+		for i := 0; i < gpus; i++ {
+			rec = append(rec, column(r.GPUMHs, i))
+		}
+		for i := 0; i < gpus; i++ {
+			rec = append(rec, column(r.Temps, i))
+		}
+		if err := cw.Write(rec); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const farmPollInterval = 5 * time.Second
+
+// FarmRig is a remote ethminer API endpoint monitored in the farm view.
+// Password is only needed for miners started with --api-password.
+type FarmRig struct {
+	Label    string `json:"label"`
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	Password string `json:"password,omitempty"`
+}
+
+func (r FarmRig) addr() string {
+	return net.JoinHostPort(r.Host, strconv.Itoa(r.Port))
+}
+
+func (r FarmRig) name() string {
+	if r.Label != "" {
+		return r.Label
+	}
+	return r.addr()
+}
+
+func (r FarmRig) Validate() error {
+	if strings.TrimSpace(r.Host) == "" {
+		return fmt.Errorf("missing host")
+	}
+	if r.Port < 1 || r.Port > 65535 {
+		return fmt.Errorf("invalid API port (1..65535)")
+	}
+	return nil
+}
+
+type rigStatus struct {
+	Rig      FarmRig
+	Online   bool
+	Stat     Stat
+	Err      string
+	LastSeen time.Time
+}
+
+// farmMonitor polls every registered rig in parallel. Each rig keeps its own
+// API connection between polls.
+type farmMonitor struct {
+	mu      sync.Mutex
+	rigs    []FarmRig
+	clients map[FarmRig]*apiClient
+	status  map[FarmRig]rigStatus
+}
+
+func newFarmMonitor(rigs []FarmRig) *farmMonitor {
+	f := &farmMonitor{clients: map[FarmRig]*apiClient{}, status: map[FarmRig]rigStatus{}}
+	f.SetRigs(rigs)
+	return f
+}
+
+// SetRigs replaces the rig list, closing connections to removed or edited
+// rigs.
+func (f *farmMonitor) SetRigs(rigs []FarmRig) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rigs = append([]FarmRig(nil), rigs...)
+	keep := make(map[FarmRig]bool, len(rigs))
+	for _, r := range rigs {
+		keep[r] = true
+	}
+	for r, c := range f.clients {
+		if !keep[r] {
+			c.Close()
+			delete(f.clients, r)
+			delete(f.status, r)
+		}
+	}
+}
+
+func (f *farmMonitor) client(r FarmRig) *apiClient {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c := f.clients[r]
+	if c == nil {
+		c = newAPIClient(r.Host, r.Port, r.Password)
+		f.clients[r] = c
+	}
+	return c
+}
+
+// PollOnce queries all rigs concurrently and returns their status in rig
+// order.
+func (f *farmMonitor) PollOnce() []rigStatus {
+	f.mu.Lock()
+	rigs := append([]FarmRig(nil), f.rigs...)
+	f.mu.Unlock()
+
+	res := make([]rigStatus, len(rigs))
+	var wg sync.WaitGroup
+	for i, r := range rigs {
+		wg.Add(1)
+		go func(i int, r FarmRig) {
+			defer wg.Done()
+			st, err := f.client(r).Stat()
+			f.mu.Lock()
+			prev := f.status[r]
+			rs := rigStatus{Rig: r, LastSeen: prev.LastSeen}
+			if err != nil {
+				rs.Err = err.Error()
+			} else {
+				rs.Online, rs.Stat, rs.LastSeen = true, st, time.Now()
+			}
+			if _, ok := f.clients[r]; ok {
+				f.status[r] = rs
+			}
+			f.mu.Unlock()
+			res[i] = rs
+		}(i, r)
+	}
+	wg.Wait()
+	return res
+}
+
+// Run polls until ctx is done, passing each round to onUpdate.
+func (f *farmMonitor) Run(ctx context.Context, onUpdate func([]rigStatus)) {
+	ticker := time.NewTicker(farmPollInterval)
+	defer ticker.Stop()
+	for {
+		onUpdate(f.PollOnce())
+		select {
+		case <-ctx.Done():
+			f.SetRigs(nil)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+type farmTotals struct {
+	KHs      int64
+	Accepted int64
+	Rejected int64
+	Online   int
+	Rigs     int
+}
+
+func sumFarm(status []rigStatus) farmTotals {
+	t := farmTotals{Rigs: len(status)}
+	for _, s := range status {
+		if !s.Online {
+			continue
+		}
+		t.Online++
+		t.KHs += s.Stat.TotalKHs
+		t.Accepted += s.Stat.Accepted
+		t.Rejected += s.Stat.Rejected
+	}
+	return t
+}
+
+// formatTemps renders per-GPU temperatures as "64/66/71°C".
+func formatTemps(temps []int) string {
+	if len(temps) == 0 {
+		return "—"
+	}
+	parts := make([]string, len(temps))
+	for i, t := range temps {
+		parts[i] = strconv.Itoa(t)
+	}
+	return strings.Join(parts, "/") + "°C"
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+var farmColumns = []string{"Rig", "Status", "Hashrate", "Shares", "Uptime", "Pool", "Temps"}
+
+// farmView is the "Farm" tab: registered rigs, farm-wide totals and a chart
+// of the combined hashrate.
+type farmView struct {
+	w        fyne.Window
+	monitor  *farmMonitor
+	rigs     []FarmRig
+	onChange func([]FarmRig)
+
+	rows      *fyne.Container
+	empty     *widget.Label
+	totalHash *widget.Label
+	totalRigs *widget.Label
+	totalShr  *widget.Label
+	chart     *hashrateChart
+	view      fyne.CanvasObject
+
+	peers      func() []discoveredRig
+	discovered *fyne.Container
+	lanTile    fyne.CanvasObject
+}
+
+func newFarmView(w fyne.Window, rigs []FarmRig, onChange func([]FarmRig)) *farmView {
+	f := &farmView{
+		w:         w,
+		monitor:   newFarmMonitor(rigs),
+		rigs:      append([]FarmRig(nil), rigs...),
+		onChange:  onChange,
+		rows:      container.NewVBox(),
+		empty:     widget.NewLabel("No rigs yet. Add a remote ethminer API endpoint to start monitoring."),
+		totalHash: widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		totalRigs: widget.NewLabel("—"),
+		totalShr:  widget.NewLabel("—"),
+		chart:     newHashrateChart(int(10 * time.Minute / farmPollInterval)),
+
+		discovered: container.NewVBox(),
+	}
+	f.empty.Wrapping = fyne.TextWrapWord
+
+	addBtn := widget.NewButtonWithIcon("Add rig", theme.ContentAddIcon(), func() { f.editRig(-1, FarmRig{Port: 3333}) })
+	addBtn.Importance = widget.HighImportance
+
+	var header []fyne.CanvasObject
+	for _, c := range farmColumns {
+		l := widget.NewLabelWithStyle(c, fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+		l.Truncation = fyne.TextTruncateEllipsis
+		header = append(header, l)
+	}
+	// Reserve the width of the row actions so the columns line up.
+	btnSize := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), nil).MinSize()
+	actions := canvas.NewRectangle(color.Transparent)
+	actions.SetMinSize(fyne.NewSize(btnSize.Width*2+theme.Padding(), 0))
+	headerRow := container.NewBorder(nil, nil, nil, actions, container.NewGridWithColumns(len(header), header...))
+
+	totals := container.NewGridWithColumns(3,
+		metricTileWithIcon("Farm hashrate", theme.MediaPlayIcon(), f.totalHash),
+		metricTileWithIcon("Rigs online", theme.ComputerIcon(), f.totalRigs),
+		metricTileWithIcon("Shares", theme.ConfirmIcon(), f.totalShr),
+	)
+	chartTitle := widget.NewLabelWithStyle("Farm hashrate (10 min)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	chart := metricTileWithHeader(container.NewHBox(widget.NewIcon(theme.HistoryIcon()), chartTitle), f.chart.Object())
+
+	lanTitle := widget.NewLabelWithStyle("Discovered on LAN", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	f.lanTile = metricTileWithHeader(container.NewHBox(widget.NewIcon(theme.SearchIcon()), lanTitle), f.discovered)
+	f.lanTile.Hide()
+
+	table := container.NewVBox(headerRow, widget.NewSeparator(), f.empty, f.rows, f.lanTile)
+	body := container.NewBorder(
+		container.NewVBox(container.NewHBox(layout.NewSpacer(), addBtn), totals, chart),
+		nil, nil, nil,
+		container.NewVScroll(table),
+	)
+	f.view = panel("Farm", body)
+	f.render(nil)
+	return f
+}
+
+func (f *farmView) Object() fyne.CanvasObject {
+	return f.view
+}
+
+// Run polls the rigs until ctx is done.
+func (f *farmView) Run(ctx context.Context) {
+	f.monitor.Run(ctx, func(status []rigStatus) {
+		fyne.Do(func() { f.render(status) })
+	})
+}
+
+// AddRig registers a rig unless one with the same address already exists.
+// It must be called on the UI goroutine.
+func (f *farmView) AddRig(r FarmRig) bool {
+	for _, x := range f.rigs {
+		if x.addr() == r.addr() {
+			return false
+		}
+	}
+	f.setRigs(append(f.rigs, r))
+	return true
+}
+
+// SetDiscovery sets the source of rigs announcing themselves on the LAN.
+func (f *farmView) SetDiscovery(peers func() []discoveredRig) {
+	f.peers = peers
+	f.RefreshDiscovered()
+}
+
+// RefreshDiscovered lists announced rigs that are not registered yet, each
+// with an "Add" action. It must be called on the UI goroutine.
+func (f *farmView) RefreshDiscovered() {
+	var objs []fyne.CanvasObject
+	if f.peers != nil {
+		registered := make(map[string]bool, len(f.rigs))
+		for _, r := range f.rigs {
+			registered[r.addr()] = true
+		}
+		for _, p := range f.peers() {
+			if registered[p.addr()] {
+				continue
+			}
+			p := p
+			text := fmt.Sprintf("%s  %s", p.Rig, p.addr())
+			if p.Version != "" {
+				text += "  ·  " + p.Version
+			}
+			label := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+			label.Truncation = fyne.TextTruncateEllipsis
+			add := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+				f.AddRig(FarmRig{Label: p.Rig, Host: p.Host, Port: p.APIPort})
+			})
+			add.Importance = widget.LowImportance
+			objs = append(objs, container.NewBorder(nil, nil, nil, add, label))
+		}
+	}
+	f.discovered.Objects = objs
+	f.discovered.Refresh()
+	if len(objs) == 0 {
+		f.lanTile.Hide()
+	} else {
+		f.lanTile.Show()
+	}
+}
+
+func (f *farmView) setRigs(rigs []FarmRig) {
+	f.rigs = rigs
+	f.monitor.SetRigs(rigs)
+	f.onChange(append([]FarmRig(nil), rigs...))
+	go func() {
+		status := f.monitor.PollOnce()
+		fyne.Do(func() { f.render(status) })
+	}()
+}
+
+func (f *farmView) render(status []rigStatus) {
+	// A poll round can finish after the rig list changed; show current rigs.
+	byAddr := make(map[string]rigStatus, len(status))
+	for _, s := range status {
+		byAddr[s.Rig.addr()] = s
+	}
+	current := make([]rigStatus, 0, len(f.rigs))
+	for _, r := range f.rigs {
+		s, ok := byAddr[r.addr()]
+		if !ok || s.Rig != r {
+			s = rigStatus{Rig: r, Err: "waiting for first poll"}
+		}
+		current = append(current, s)
+	}
+
+	objs := make([]fyne.CanvasObject, 0, len(current))
+	for i, s := range current {
+		objs = append(objs, f.row(i, s))
+	}
+	f.rows.Objects = objs
+	f.rows.Refresh()
+	f.RefreshDiscovered()
+	if len(current) == 0 {
+		f.empty.Show()
+	} else {
+		f.empty.Hide()
+	}
+
+	t := sumFarm(current)
+	f.totalRigs.SetText(fmt.Sprintf("%d / %d", t.Online, t.Rigs))
+	if t.Online == 0 {
+		f.totalHash.SetText("—")
+		f.totalShr.SetText("—")
+		return
+	}
+	f.totalHash.SetText(fmt.Sprintf("%.2f MH/s", float64(t.KHs)/1000.0))
+	f.totalShr.SetText(fmt.Sprintf("Accepted %d | Rejected %d", t.Accepted, t.Rejected))
+	if status != nil {
+		f.chart.Add(float64(t.KHs) / 1000.0)
+	}
+}
+
+func (f *farmView) row(i int, s rigStatus) fyne.CanvasObject {
+	cell := func(text string) *widget.Label {
+		l := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		l.Truncation = fyne.TextTruncateEllipsis
+		return l
+	}
+	name := widget.NewLabelWithStyle(s.Rig.name(), fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	name.Truncation = fyne.TextTruncateEllipsis
+
+	state := cell("ONLINE")
+	state.Importance = widget.SuccessImportance
+	cols := []fyne.CanvasObject{name, state}
+	if s.Online {
+		st := s.Stat
+		cols = append(cols,
+			cell(fmt.Sprintf("%.2f MH/s", float64(st.TotalKHs)/1000.0)),
+			cell(fmt.Sprintf("A %d | R %d", st.Accepted, st.Rejected)),
+			cell(fmt.Sprintf("%d min", st.UptimeMin)),
+			cell(orDash(poolHost(st.Pool))),
+			cell(formatTemps(st.Temps)),
+		)
+	} else {
+		state.SetText("OFFLINE")
+		state.Importance = widget.DangerImportance
+		reason := cell(s.Err)
+		if !s.LastSeen.IsZero() {
+			reason.SetText(fmt.Sprintf("last seen %s ago: %s", time.Since(s.LastSeen).Round(time.Second), s.Err))
+		}
+		cols = append(cols, reason, cell(""), cell(""), cell(""), cell(""))
+	}
+
+	editBtn := widget.NewButtonWithIcon("", theme.DocumentCreateIcon(), func() { f.editRig(i, s.Rig) })
+	editBtn.Importance = widget.LowImportance
+	removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		dialog.ShowConfirm("Remove rig", fmt.Sprintf("Stop monitoring %s?", s.Rig.name()), func(ok bool) {
+			if !ok || i >= len(f.rigs) {
+				return
+			}
+			rigs := append([]FarmRig(nil), f.rigs[:i]...)
+			f.setRigs(append(rigs, f.rigs[i+1:]...))
+		}, f.w)
+	})
+	removeBtn.Importance = widget.LowImportance
+	return container.NewBorder(nil, nil, nil, container.NewHBox(editBtn, removeBtn), container.NewGridWithColumns(len(cols), cols...))
+}
+
+// editRig opens the rig form. index -1 adds a new rig.
+func (f *farmView) editRig(index int, r FarmRig) {
+	label := widget.NewEntry()
+	label.SetText(r.Label)
+	label.SetPlaceHolder("e.g. rig-03")
+	host := widget.NewEntry()
+	host.SetText(r.Host)
+	host.SetPlaceHolder("192.168.1.23")
+	port := widget.NewEntry()
+	port.SetText(strconv.Itoa(r.Port))
+	password := widget.NewPasswordEntry()
+	password.SetText(r.Password)
+	password.SetPlaceHolder("optional (--api-password)")
+
+	title := "Add rig"
+	if index >= 0 {
+		title = "Edit rig"
+	}
+	items := []*widget.FormItem{
+		widget.NewFormItem("Label", label),
+		widget.NewFormItem("Host", host),
+		widget.NewFormItem("API port", port),
+		widget.NewFormItem("Password", password),
+	}
+	d := dialog.NewForm(title, "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		p, err := strconv.Atoi(strings.TrimSpace(port.Text))
+		if err != nil {
+			p = 0
+		}
+		rig := FarmRig{
+			Label:    strings.TrimSpace(label.Text),
+			Host:     strings.TrimSpace(host.Text),
+			Port:     p,
+			Password: password.Text,
+		}
+		if err := rig.Validate(); err != nil {
+			dialog.ShowError(err, f.w)
+			return
+		}
+		rigs := append([]FarmRig(nil), f.rigs...)
+		for i, x := range rigs {
+			if i != index && x.addr() == rig.addr() {
+				dialog.ShowError(errors.New("a rig with this host and port is already registered"), f.w)
+				return
+			}
+		}
+		if index >= 0 && index < len(rigs) {
+			rigs[index] = rig
+		} else {
+			rigs = append(rigs, rig)
+		}
+		f.setRigs(rigs)
+	}, f.w)
+	d.Resize(fyne.NewSize(420, 0))
+	d.Show()
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+const (
+	// gpuHotTemp flags a card as running hot (°C).
+	gpuHotTemp = 80
+	// gpuLowHashrate flags a card hashing below this fraction of its own
+	// recent average.
+	gpuLowHashrate = 0.7
+	// gpuSparkPoints is the sparkline history per card.
+	gpuSparkPoints = 30
+)
+
+// gpuKey identifies a GPU by the instance that drives it and the miner's own
+// device index, which is what miner_pausegpu expects.
+type gpuKey struct {
+	Instance string
+	Index    int
+}
+
+// gpuRowData is one GPU as reported by the latest stats.
+type gpuRowData struct {
+	Key    gpuKey
+	Name   string
+	PCI    string
+	KHs    int64
+	Temp   int
+	Fan    int
+	Paused bool
+	// PauseKnown is false for getstat1 data, which has no pause state; the
+	// last requested state is shown instead.
+	PauseKnown  bool
+	PauseReason string
+}
+
+type gpuRow struct {
+	name     *widget.Label
+	pci      *widget.Label
+	hashrate *widget.Label
+	temp     *widget.Label
+	fan      *widget.Label
+	share    *widget.Label
+	spark    *sparkline
+	badge    *widget.Label
+	btn      *widget.Button
+	bg       *canvas.Rectangle
+	obj      fyne.CanvasObject
+
+	paused bool
+	// pending is the requested state while the miner hasn't confirmed it.
+	pending *bool
+}
+
+// gpuPanel is a live table of the running miner's GPUs with a pause/resume
+// button per card. Rows running hot or well below their own recent hashrate
+// are highlighted. All methods must be called on the UI goroutine.
+type gpuPanel struct {
+	header  fyne.CanvasObject
+	box     *fyne.Container
+	view    fyne.CanvasObject
+	rows    map[gpuKey]*gpuRow
+	order   []gpuKey
+	onPause func(key gpuKey, pause bool)
+}
+
+func newGPUPanel(onPause func(key gpuKey, pause bool)) *gpuPanel {
+	p := &gpuPanel{
+		box:     container.NewVBox(),
+		rows:    map[gpuKey]*gpuRow{},
+		onPause: onPause,
+	}
+	title := widget.NewLabelWithStyle("GPUs", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	title.Wrapping = fyne.TextWrapOff
+
+	var cols []fyne.CanvasObject
+	for _, c := range []string{"Name", "PCI", "Hashrate", "Temp", "Fan", "Share", "Trend"} {
+		l := widget.NewLabelWithStyle(c, fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+		l.Truncation = fyne.TextTruncateEllipsis
+		cols = append(cols, l)
+	}
+	// Reserve the width of the row actions so the columns line up.
+	spacer := canvas.NewRectangle(color.Transparent)
+	spacer.SetMinSize(widget.NewButtonWithIcon("Resume", theme.MediaPlayIcon(), nil).MinSize())
+	p.header = container.NewBorder(nil, nil, nil, spacer, container.NewGridWithColumns(len(cols), cols...))
+
+	body := container.NewVBox(p.header, p.box)
+	p.view = metricTileWithHeader(container.NewHBox(widget.NewIcon(theme.ComputerIcon()), title), body)
+	p.view.Hide()
+	return p
+}
+
+func (p *gpuPanel) Object() fyne.CanvasObject {
+	return p.view
+}
+
+func (p *gpuPanel) newRow(key gpuKey) *gpuRow {
+	cell := func() *widget.Label {
+		l := widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		l.Truncation = fyne.TextTruncateEllipsis
+		return l
+	}
+	r := &gpuRow{
+		name:     widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		pci:      cell(),
+		hashrate: cell(),
+		temp:     cell(),
+		fan:      cell(),
+		share:    cell(),
+		spark:    newSparkline(gpuSparkPoints),
+		badge:    widget.NewLabelWithStyle("PAUSED", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		bg:       canvas.NewRectangle(color.Transparent),
+	}
+	r.name.Truncation = fyne.TextTruncateEllipsis
+	r.badge.Importance = widget.WarningImportance
+	r.badge.Hide()
+	r.btn = widget.NewButtonWithIcon("Pause", theme.MediaPauseIcon(), func() {
+		pause := !r.paused
+		r.pending = &pause
+		r.btn.Disable()
+		p.onPause(key, pause)
+	})
+	r.btn.Importance = widget.LowImportance
+	// The badge sits under the name so pausing doesn't shift the columns.
+	name := container.NewVBox(r.name, r.badge)
+	cols := container.NewGridWithColumns(7, name, r.pci, r.hashrate, r.temp, r.fan, r.share, r.spark.Object())
+	r.obj = container.NewMax(r.bg, container.NewBorder(nil, nil, nil, r.btn, cols))
+	return r
+}
+
+// Update shows the given GPUs, rebuilding the list only when the set of
+// GPUs changes.
+func (p *gpuPanel) Update(data []gpuRowData) {
+	keys := make([]gpuKey, 0, len(data))
+	for _, d := range data {
+		keys = append(keys, d.Key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Instance != keys[j].Instance {
+			return keys[i].Instance < keys[j].Instance
+		}
+		return keys[i].Index < keys[j].Index
+	})
+	if !sameGPUKeys(keys, p.order) {
+		rows := make(map[gpuKey]*gpuRow, len(keys))
+		objs := make([]fyne.CanvasObject, 0, len(keys))
+		for _, k := range keys {
+			r := p.rows[k]
+			if r == nil {
+				r = p.newRow(k)
+			}
+			rows[k] = r
+			objs = append(objs, r.obj)
+		}
+		p.rows, p.order = rows, keys
+		p.box.Objects = objs
+		p.box.Refresh()
+	}
+
+	var total int64
+	for _, d := range data {
+		total += d.KHs
+	}
+
+	for _, d := range data {
+		r := p.rows[d.Key]
+		r.name.SetText(d.Name)
+		r.pci.SetText(orDash(d.PCI))
+		r.hashrate.SetText(fmt.Sprintf("%.2f MH/s", float64(d.KHs)/1000.0))
+		if d.Temp > 0 {
+			r.temp.SetText(fmt.Sprintf("%d°C", d.Temp))
+		} else {
+			r.temp.SetText("—")
+		}
+		r.fan.SetText(fmt.Sprintf("%d%%", d.Fan))
+		if total > 0 {
+			r.share.SetText(fmt.Sprintf("%.1f%%", float64(d.KHs)*100/float64(total)))
+		} else {
+			r.share.SetText("—")
+		}
+
+		paused := r.paused
+		switch {
+		case !d.PauseKnown:
+			if r.pending != nil {
+				paused = *r.pending
+				r.pending = nil
+			}
+		case r.pending == nil:
+			paused = d.Paused
+		case d.Paused == *r.pending:
+			// The miner has applied the request.
+			paused = d.Paused
+			r.pending = nil
+		}
+		r.paused = paused
+
+		if paused {
+			text := "PAUSED"
+			if d.PauseReason != "" && d.PauseReason != "api" {
+				text = "PAUSED: " + d.PauseReason
+			}
+			r.badge.SetText(text)
+			r.badge.Show()
+			r.btn.SetText("Resume")
+			r.btn.SetIcon(theme.MediaPlayIcon())
+		} else {
+			r.badge.Hide()
+			r.btn.SetText("Pause")
+			r.btn.SetIcon(theme.MediaPauseIcon())
+		}
+		if r.pending == nil {
+			r.btn.Enable()
+		}
+
+		mhs := float64(d.KHs) / 1000.0
+		avg, haveAvg := r.spark.Average(gpuSparkPoints / 3)
+		abnormal := d.Temp >= gpuHotTemp ||
+			(!paused && haveAvg && mhs < avg*gpuLowHashrate)
+		if !paused {
+			// Keep paused zeros out of the trend so resuming isn't flagged.
+			r.spark.Add(mhs)
+		}
+		var fill color.Color = color.Transparent
+		if abnormal {
+			c := toNRGBA(theme.Color(theme.ColorNameWarning))
+			c.A = 0x40
+			fill = c
+		}
+		if r.bg.FillColor != fill {
+			r.bg.FillColor = fill
+			r.bg.Refresh()
+		}
+	}
+	if len(data) > 0 {
+		p.view.Show()
+	}
+}
+
+// SetPaused records a pause state applied outside the row buttons (e.g. the
+// pause-all action) so getstat1-only miners show it too.
+func (p *gpuPanel) SetPaused(pause bool) {
+	for _, r := range p.rows {
+		v := pause
+		r.pending = &v
+	}
+}
+
+// Failed drops the pending request for key after the control call failed.
+func (p *gpuPanel) Failed(key gpuKey) {
+	if r := p.rows[key]; r != nil {
+		r.pending = nil
+		r.btn.Enable()
+	}
+}
+
+func (p *gpuPanel) Reset() {
+	p.rows = map[gpuKey]*gpuRow{}
+	p.order = nil
+	p.box.Objects = nil
+	p.box.Refresh()
+	p.view.Hide()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "—"
+	}
+	return s
+}
+
+func sameGPUKeys(a, b []gpuKey) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// gpuRowsFor maps per-instance device stats onto the detected devices. The
+// miner numbers only the devices it was given, so its index is a position in
+// the instance's device selection.
+func gpuRowsFor(snaps []instanceSnapshot, devices []Device) []gpuRowData {
+	var rows []gpuRowData
+	for _, sn := range snaps {
+		if !sn.HasStat {
+			continue
+		}
+		for i, ds := range sn.Stat.Devices {
+			sysIdx := ds.Index
+			if ds.Index >= 0 && ds.Index < len(sn.Devices) {
+				sysIdx = sn.Devices[ds.Index]
+			}
+			row := gpuRowData{
+				Key:         gpuKey{Instance: sn.Name, Index: ds.Index},
+				Name:        ds.Name,
+				PCI:         ds.PCI,
+				KHs:         ds.KHs,
+				Temp:        ds.Temp,
+				Fan:         ds.Fan,
+				Paused:      ds.Paused,
+				PauseKnown:  sn.Stat.Detail,
+				PauseReason: ds.PauseReason,
+			}
+			for _, d := range devices {
+				if d.Index == sysIdx && d.Backend == sn.Backend {
+					if row.Name == "" {
+						row.Name = d.Name
+					}
+					if row.PCI == "" {
+						row.PCI = d.PCI
+					}
+					break
+				}
+			}
+			if row.Name == "" {
+				row.Name = fmt.Sprintf("GPU %d", i)
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
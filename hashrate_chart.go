@@ -6,6 +6,7 @@ import (
 	"image/color"
 	"math"
 	"sync"
+	"time"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
@@ -20,6 +21,7 @@ type hashrateChart struct {
 
 	mu     sync.Mutex
 	points []float64
+	times  []time.Time
 
 	axisMin  float64
 	axisMax  float64
@@ -160,6 +162,16 @@ func newHashrateChart(maxPoints int) *hashrateChart {
 	return c
 }
 
+// Window describes the time span the chart covers when a point is added
+// every interval, e.g. "10 min".
+func (c *hashrateChart) Window(interval int) string {
+	minutes := c.maxPoints * interval / 60
+	if minutes >= 120 {
+		return fmt.Sprintf("%d h", minutes/60)
+	}
+	return fmt.Sprintf("%d min", minutes)
+}
+
 func (c *hashrateChart) Object() fyne.CanvasObject {
 	return c.view
 }
@@ -170,8 +182,10 @@ func (c *hashrateChart) Add(mhs float64) {
 	}
 	c.mu.Lock()
 	c.points = append(c.points, mhs)
+	c.times = append(c.times, time.Now())
 	if len(c.points) > c.maxPoints {
 		c.points = c.points[len(c.points)-c.maxPoints:]
+		c.times = c.times[len(c.times)-c.maxPoints:]
 	}
 	axisMin, axisMax, axisStep := c.axisRangeLocked()
 	c.axisMin = axisMin
@@ -182,6 +196,31 @@ func (c *hashrateChart) Add(mhs float64) {
 	c.raster.Refresh()
 }
 
+// SetPoints replaces the data with mhs, e.g. with points loaded from the
+// history. It is not trimmed to maxPoints.
+func (c *hashrateChart) SetPoints(mhs []float64) {
+	c.mu.Lock()
+	c.points = append([]float64(nil), mhs...)
+	c.times = nil
+	axisMin, axisMax, axisStep := c.axisRangeLocked()
+	c.axisMin = axisMin
+	c.axisMax = axisMax
+	c.axisStep = axisStep
+	c.mu.Unlock()
+	c.setScale(axisMin, axisMax, axisStep)
+	c.raster.Refresh()
+}
+
+// Samples returns the points added with Add and when they were added.
+func (c *hashrateChart) Samples() ([]time.Time, []float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.times) != len(c.points) {
+		return nil, nil
+	}
+	return append([]time.Time(nil), c.times...), append([]float64(nil), c.points...)
+}
+
 func (c *hashrateChart) Average() (float64, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -198,6 +237,7 @@ func (c *hashrateChart) Average() (float64, bool) {
 func (c *hashrateChart) Reset() {
 	c.mu.Lock()
 	c.points = nil
+	c.times = nil
 	c.axisMin = 0
 	c.axisMax = 0
 	c.axisStep = 0
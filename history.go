@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const historyDirName = "history"
+
+// historyRes is one resolution of the on-disk history. Each resolution is a
+// JSON Lines file of points rolled up from the next finer one.
+type historyRes struct {
+	Name string
+	Step time.Duration
+	Keep time.Duration
+}
+
+// historyResolutions is the rollup chain and its retention policy, finest
+// first. Buckets are aligned to UTC.
+var historyResolutions = []historyRes{
+	{Name: "1m", Step: time.Minute, Keep: 7 * 24 * time.Hour},
+	{Name: "1h", Step: time.Hour, Keep: 180 * 24 * time.Hour},
+	{Name: "1d", Step: 24 * time.Hour, Keep: 5 * 365 * 24 * time.Hour},
+}
+
+const (
+	historyMinute = iota
+	historyHour
+	historyDay
+)
+
+// historyPoint summarizes the samples of one bucket. Hashrates and
+// temperatures are averages over N samples; shares are counted within the
+// bucket.
+type historyPoint struct {
+	T        time.Time `json:"t"`
+	N        int       `json:"n"`
+	KHs      float64   `json:"khs"`
+	MaxKHs   int64     `json:"maxKhs"`
+	GPUKHs   []float64 `json:"gpuKhs,omitempty"`
+	Temps    []float64 `json:"temps,omitempty"`
+	Accepted int64     `json:"accepted"`
+	Rejected int64     `json:"rejected"`
+	Invalid  int64     `json:"invalid"`
+	// Starts and Ends count mining sessions beginning and ending in the
+	// bucket.
+	Starts int `json:"starts,omitempty"`
+	Ends   int `json:"ends,omitempty"`
+}
+
+func (p *historyPoint) merge(q historyPoint) {
+	if q.N == 0 {
+		return
+	}
+	p.KHs = (p.KHs*float64(p.N) + q.KHs*float64(q.N)) / float64(p.N+q.N)
+	p.GPUKHs = mergeAverages(p.GPUKHs, p.N, q.GPUKHs, q.N)
+	p.Temps = mergeAverages(p.Temps, p.N, q.Temps, q.N)
+	p.N += q.N
+	p.MaxKHs = max(p.MaxKHs, q.MaxKHs)
+	p.Accepted += q.Accepted
+	p.Rejected += q.Rejected
+	p.Invalid += q.Invalid
+	p.Starts += q.Starts
+	p.Ends += q.Ends
+}
+
+// mergeAverages combines per-GPU averages weighted by sample counts. A GPU
+// missing from one side only takes the other side's value.
+func mergeAverages(a []float64, an int, b []float64, bn int) []float64 {
+	res := make([]float64, max(len(a), len(b)))
+	for i := range res {
+		switch {
+		case i >= len(a):
+			res[i] = b[i]
+		case i >= len(b):
+			res[i] = a[i]
+		default:
+			res[i] = (a[i]*float64(an) + b[i]*float64(bn)) / float64(an+bn)
+		}
+	}
+	if len(res) == 0 {
+		return nil
+	}
+	return res
+}
+
+// historyStore records polled stats into per-minute points and rolls them
+// up into hours and days. All points are kept in memory; the files are
+// appended to and only rewritten when expired points are pruned.
+type historyStore struct {
+	dir string
+
+	mu     sync.Mutex
+	series [][]historyPoint
+	cur    historyPoint // the open minute; N == 0 when there is none
+
+	// hasLast is set while a mining run is being recorded; last holds the
+	// share counters of its miner processes as of the previous sample.
+	hasLast bool
+	last    map[string]shareCounts
+}
+
+// shareCounts are the cumulative share counters of one miner process.
+type shareCounts struct {
+	Accepted, Rejected, Invalid int64
+}
+
+func historyDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configDirName, historyDirName), nil
+}
+
+// openHistory loads the history in dir, rolls up buckets completed while
+// the app was closed and prunes expired points as of now.
+func openHistory(dir string, now time.Time) (*historyStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	h := &historyStore{dir: dir, series: make([][]historyPoint, len(historyResolutions))}
+	for i, res := range historyResolutions {
+		pts, err := readHistoryFile(h.path(i))
+		if err != nil {
+			return nil, fmt.Errorf("history %s: %w", res.Name, err)
+		}
+		h.series[i] = pts
+	}
+	if err := h.rollupLocked(now); err != nil {
+		return nil, err
+	}
+	if err := h.pruneLocked(now); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *historyStore) path(i int) string {
+	return filepath.Join(h.dir, historyResolutions[i].Name+".jsonl")
+}
+
+func readHistoryFile(path string) ([]historyPoint, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pts []historyPoint
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for sc.Scan() {
+		var p historyPoint
+		if json.Unmarshal(sc.Bytes(), &p) != nil || p.N <= 0 {
+			// A torn last line after a crash; skip it.
+			continue
+		}
+		pts = appendPoint(pts, p)
+	}
+	return pts, sc.Err()
+}
+
+// appendPoint adds p in time order, merging it into the last point when
+// both cover the same bucket (a minute flushed on stop and resumed).
+func appendPoint(pts []historyPoint, p historyPoint) []historyPoint {
+	if n := len(pts); n > 0 {
+		switch last := &pts[n-1]; {
+		case p.T.Equal(last.T):
+			last.merge(p)
+			return pts
+		case p.T.Before(last.T):
+			// Clock went backwards; keep the series ordered.
+			return pts
+		}
+	}
+	return append(pts, p)
+}
+
+func (h *historyStore) writeLocked(i int, p historyPoint) error {
+	h.series[i] = appendPoint(h.series[i], p)
+	b, _ := json.Marshal(p)
+	return appendLine(h.path(i), b)
+}
+
+// appendLine appends b as one line to the JSON Lines file at path. A torn
+// last line left by a crash is terminated first so that it doesn't swallow
+// b; readers skip it as junk.
+func appendLine(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	line := append(b, '\n')
+	if fi, err := f.Stat(); err == nil && fi.Size() > 0 {
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, fi.Size()-1); err == nil && last[0] != '\n' {
+			line = append([]byte{'\n'}, line...)
+		}
+	}
+	_, err = f.Write(line)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Record adds one polled sample taken at t. runs holds the share counters of
+// each miner process keyed by a run identifier; s.Accepted and friends are
+// ignored since their sum drops whenever one process restarts.
+func (h *historyStore) Record(t time.Time, s Stat, runs map[string]shareCounts) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	bucket := t.UTC().Truncate(time.Minute)
+	if h.cur.N > 0 && !h.cur.T.Equal(bucket) {
+		if err := h.commitLocked(t); err != nil {
+			return err
+		}
+	}
+
+	sample := historyPoint{T: bucket, N: 1, KHs: float64(s.TotalKHs), MaxKHs: s.TotalKHs}
+	if !h.hasLast {
+		sample.Starts = 1
+	}
+	for _, kh := range s.PerGPU_KHs {
+		sample.GPUKHs = append(sample.GPUKHs, float64(kh))
+	}
+	for _, temp := range s.Temps {
+		sample.Temps = append(sample.Temps, float64(temp))
+	}
+	// Share counters are cumulative per miner process and restart from zero.
+	delta := func(cur, last int64) int64 {
+		if cur < last {
+			return cur
+		}
+		return cur - last
+	}
+	for key, c := range runs {
+		last := h.last[key]
+		sample.Accepted += delta(c.Accepted, last.Accepted)
+		sample.Rejected += delta(c.Rejected, last.Rejected)
+		sample.Invalid += delta(c.Invalid, last.Invalid)
+	}
+	h.last, h.hasLast = runs, true
+
+	if h.cur.N == 0 {
+		h.cur = sample
+	} else {
+		h.cur.merge(sample)
+	}
+	return nil
+}
+
+// Flush writes the open minute, e.g. when mining stops. The next sample
+// starts a new run of share counters.
+func (h *historyStore) Flush(now time.Time) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.hasLast, h.last = false, nil
+	if h.cur.N == 0 {
+		return nil
+	}
+	h.cur.Ends++
+	return h.commitLocked(now)
+}
+
+func (h *historyStore) commitLocked(now time.Time) error {
+	p := h.cur
+	h.cur = historyPoint{}
+	if err := h.writeLocked(historyMinute, p); err != nil {
+		return err
+	}
+	rolledDay := len(h.series[historyDay])
+	if err := h.rollupLocked(now); err != nil {
+		return err
+	}
+	if len(h.series[historyDay]) != rolledDay {
+		return h.pruneLocked(now)
+	}
+	return nil
+}
+
+// rollupLocked writes every coarse bucket that is complete as of now and not
+// stored yet.
+func (h *historyStore) rollupLocked(now time.Time) error {
+	for i := 1; i < len(historyResolutions); i++ {
+		for _, p := range rollup(h.series[i-1], h.series[i], historyResolutions[i].Step, now) {
+			if err := h.writeLocked(i, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rollup groups the fine points after the last coarse one into buckets of
+// step. Only buckets that ended before now are returned unless now is zero.
+func rollup(fine, coarse []historyPoint, step time.Duration, now time.Time) []historyPoint {
+	var after time.Time
+	if n := len(coarse); n > 0 {
+		after = coarse[n-1].T.Add(step)
+	}
+	open := now.UTC().Truncate(step)
+	var res []historyPoint
+	for _, p := range fine {
+		b := p.T.Truncate(step)
+		if b.Before(after) || (!now.IsZero() && !b.Before(open)) {
+			continue
+		}
+		if n := len(res); n > 0 && res[n-1].T.Equal(b) {
+			res[n-1].merge(p)
+			continue
+		}
+		p.T = b
+		p.GPUKHs = append([]float64(nil), p.GPUKHs...)
+		p.Temps = append([]float64(nil), p.Temps...)
+		res = append(res, p)
+	}
+	return res
+}
+
+// pruneLocked drops points past their retention and rewrites the files
+// that changed.
+func (h *historyStore) pruneLocked(now time.Time) error {
+	for i, res := range historyResolutions {
+		cutoff := now.Add(-res.Keep)
+		pts := h.series[i]
+		n := 0
+		for n < len(pts) && pts[n].T.Before(cutoff) {
+			n++
+		}
+		if n == 0 {
+			continue
+		}
+		h.series[i] = append([]historyPoint(nil), pts[n:]...)
+		if err := writeHistoryFile(h.path(i), h.series[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHistoryFile(path string, pts []historyPoint) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, p := range pts {
+		if err = enc.Encode(p); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Points returns the points of resolution i whose buckets overlap
+// [from, to), including the open minute and partial coarse buckets not
+// rolled up yet.
+func (h *historyStore) Points(i int, from, to time.Time) []historyPoint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	pts := append([]historyPoint(nil), h.series[historyMinute]...)
+	if h.cur.N > 0 {
+		pts = appendPoint(pts, h.cur)
+	}
+	for r := 1; r <= i; r++ {
+		pts = append(append([]historyPoint(nil), h.series[r]...), rollup(pts, h.series[r], historyResolutions[r].Step, time.Time{})...)
+	}
+
+	step := historyResolutions[i].Step
+	var res []historyPoint
+	for _, p := range pts {
+		if p.T.Add(step).After(from) && p.T.Before(to) {
+			res = append(res, p)
+		}
+	}
+	return res
+}
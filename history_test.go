@@ -0,0 +1,95 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestHistorySharesAcrossInstanceRestart(t *testing.T) {
+	h, err := openHistory(t.TempDir(), time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	samples := []map[string]shareCounts{
+		{"cuda/10": {Accepted: 100}, "opencl/11": {Accepted: 50}},
+		{"cuda/10": {Accepted: 110}, "opencl/11": {Accepted: 55}},
+		// The OpenCL process restarted and its counters started over, so the
+		// rig-wide sum drops from 165 to 115.
+		{"cuda/10": {Accepted: 112}, "opencl/12": {Accepted: 3, Rejected: 1}},
+		{"cuda/10": {Accepted: 120}, "opencl/12": {Accepted: 4, Rejected: 1}},
+	}
+	for i, runs := range samples {
+		if err := h.Record(t0.Add(time.Duration(i)*10*time.Second), Stat{TotalKHs: 1000}, runs); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pts := h.Points(historyMinute, t0, t0.Add(time.Minute))
+	if len(pts) != 1 {
+		t.Fatalf("points = %+v, want one minute", pts)
+	}
+	if p := pts[0]; p.Accepted != 179 || p.Rejected != 1 || p.Starts != 1 {
+		t.Errorf("accepted %d rejected %d starts %d, want 179, 1, 1", p.Accepted, p.Rejected, p.Starts)
+	}
+
+	// After a stop the next run counts from its first sample again.
+	if err := h.Flush(t0.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	t1 := t0.Add(2 * time.Minute)
+	if err := h.Record(t1, Stat{}, map[string]shareCounts{"cuda/10": {Accepted: 7}}); err != nil {
+		t.Fatal(err)
+	}
+	if p := h.Points(historyMinute, t1, t1.Add(time.Minute)); len(p) != 1 || p[0].Accepted != 7 || p[0].Starts != 1 {
+		t.Errorf("after flush = %+v, want 7 accepted in a new run", p)
+	}
+}
+
+func TestAppendLineTerminatesTornLine(t *testing.T) {
+	path := t.TempDir() + "/x.jsonl"
+	if err := os.WriteFile(path, []byte("{\"a\":1}\n{\"a\":"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendLine(path, []byte(`{"a":2}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := appendLine(path, []byte(`{"a":3}`)); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "{\"a\":1}\n{\"a\":\n{\"a\":2}\n{\"a\":3}\n"; string(b) != want {
+		t.Errorf("file = %q, want %q", b, want)
+	}
+}
+
+func TestHistorySkipsTornLine(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	h, err := openHistory(dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	torn := `{"t":"2024-01-01T11:58:00Z","n":3,"khs":1`
+	if err := os.WriteFile(h.path(historyMinute), []byte(torn), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Record(now, Stat{TotalKHs: 1000}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Flush(now); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err = openHistory(dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts := h.series[historyMinute]
+	if len(pts) != 1 || pts[0].KHs != 1000 {
+		t.Errorf("points after reopening = %+v, want the flushed minute", pts)
+	}
+}
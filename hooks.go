@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	hookPreStart  = "pre-start"
+	hookPostStart = "post-start"
+	hookPreStop   = "pre-stop"
+	hookPostExit  = "post-exit"
+	hookOnCrash   = "on-crash"
+
+	defaultHookTimeout = 30
+)
+
+// HooksConfig holds user commands run around the miner lifecycle. Commands
+// are passed to the system shell, so pipes and arguments work as typed.
+type HooksConfig struct {
+	PreStart   string `json:"preStart,omitempty"`
+	PostStart  string `json:"postStart,omitempty"`
+	PreStop    string `json:"preStop,omitempty"`
+	PostExit   string `json:"postExit,omitempty"`
+	OnCrash    string `json:"onCrash,omitempty"`
+	TimeoutSec int    `json:"timeoutSec,omitempty"`
+}
+
+func (h HooksConfig) command(event string) string {
+	switch event {
+	case hookPreStart:
+		return h.PreStart
+	case hookPostStart:
+		return h.PostStart
+	case hookPreStop:
+		return h.PreStop
+	case hookPostExit:
+		return h.PostExit
+	case hookOnCrash:
+		return h.OnCrash
+	default:
+		return ""
+	}
+}
+
+func (h HooksConfig) timeout() time.Duration {
+	if h.TimeoutSec <= 0 {
+		return defaultHookTimeout * time.Second
+	}
+	return time.Duration(h.TimeoutSec) * time.Second
+}
+
+func (h HooksConfig) count() int {
+	n := 0
+	for _, c := range []string{h.PreStart, h.PostStart, h.PreStop, h.PostExit, h.OnCrash} {
+		if strings.TrimSpace(c) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// hookContext is exported to hook commands as OLIVETUM_* variables.
+type hookContext struct {
+	Mode     string
+	Backend  string
+	Instance string
+	Devices  string
+	PoolURL  string
+	ExitCode *int
+}
+
+func (hc hookContext) env(event string) []string {
+	env := []string{
+		"OLIVETUM_HOOK=" + event,
+		"OLIVETUM_MODE=" + hc.Mode,
+		"OLIVETUM_BACKEND=" + hc.Backend,
+		"OLIVETUM_INSTANCE=" + hc.Instance,
+		"OLIVETUM_DEVICES=" + hc.Devices,
+		"OLIVETUM_POOL_URL=" + hc.PoolURL,
+	}
+	if hc.ExitCode != nil {
+		env = append(env, "OLIVETUM_EXIT_CODE="+strconv.Itoa(*hc.ExitCode))
+	}
+	return env
+}
+
+// runHookContext describes a whole run. Mixed runs report backend "mixed" and
+// devices as "backend:index" pairs.
+func runHookContext(cfg *Config, planned []*minerInstance) hookContext {
+	poolURL, _ := buildPoolURL(cfg)
+	if len(planned) == 1 {
+		return instanceHookContext(cfg, planned[0], poolURL)
+	}
+	var devs []string
+	for _, inst := range planned {
+		for _, idx := range inst.Devices {
+			devs = append(devs, fmt.Sprintf("%s:%d", inst.Backend, idx))
+		}
+	}
+	return hookContext{
+		Mode:    cfg.Mode,
+		Backend: backendMixed,
+		Devices: strings.Join(devs, ","),
+		PoolURL: poolURL,
+	}
+}
+
+func instanceHookContext(cfg *Config, inst *minerInstance, poolURL string) hookContext {
+	devs := make([]string, 0, len(inst.Devices))
+	for _, idx := range inst.Devices {
+		devs = append(devs, strconv.Itoa(idx))
+	}
+	return hookContext{
+		Mode:     cfg.Mode,
+		Backend:  inst.Backend,
+		Instance: inst.Name,
+		Devices:  strings.Join(devs, ","),
+		PoolURL:  poolURL,
+	}
+}
+
+// exitCodeOf maps a cmd.Wait error to a process exit code, or -1 if the
+// process did not exit normally.
+func exitCodeOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// runHook runs the command configured for event, streaming its output to
+// logLine with a [hook] prefix. An empty command is a no-op. Cancelling ctx
+// kills the command.
+func runHook(ctx context.Context, hooks HooksConfig, event string, hc hookContext, logLine func(string)) error {
+	command := strings.TrimSpace(hooks.command(event))
+	if command == "" {
+		return nil
+	}
+	prefixed := func(line string) { logLine("[hook] " + line) }
+	prefixed(fmt.Sprintf("%s: %s", event, command))
+
+	ctx, cancel := context.WithTimeout(ctx, hooks.timeout())
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	configureChildProcess(cmd)
+	cmd.Env = append(os.Environ(), hc.env(event)...)
+	// Don't hang on pipes kept open by background children of the hook.
+	cmd.WaitDelay = 2 * time.Second
+
+	stdout, _ := cmd.StdoutPipe()
+	stderr, _ := cmd.StderrPipe()
+	if err := cmd.Start(); err != nil {
+		prefixed(fmt.Sprintf("%s failed: %v", event, err))
+		return err
+	}
+	go streamLines(stdout, prefixed)
+	go streamLines(stderr, prefixed)
+
+	err := cmd.Wait()
+	switch ctx.Err() {
+	case nil:
+	case context.DeadlineExceeded:
+		err = fmt.Errorf("timed out after %s", hooks.timeout())
+	default:
+		err = errors.New("cancelled")
+	}
+	if err != nil {
+		prefixed(fmt.Sprintf("%s failed: %v", event, err))
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunHookCancel(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+	logLine := func(s string) {
+		mu.Lock()
+		lines = append(lines, s)
+		mu.Unlock()
+	}
+	hooks := HooksConfig{PreStart: "sleep 30", TimeoutSec: 60}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := runHook(ctx, hooks, hookPreStart, hookContext{}, logLine)
+	if err == nil || err.Error() != "cancelled" {
+		t.Errorf("err = %v, want cancelled", err)
+	}
+	if d := time.Since(start); d > 10*time.Second {
+		t.Errorf("cancelled hook ran for %s", d)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(lines, "\n"); !strings.Contains(got, "[hook] pre-start failed: cancelled") {
+		t.Errorf("log = %q", got)
+	}
+}
+
+func TestRunHookTimeout(t *testing.T) {
+	hooks := HooksConfig{PreStart: "sleep 30", TimeoutSec: 1}
+	err := runHook(context.Background(), hooks, hookPreStart, hookContext{}, func(string) {})
+	if err == nil || !strings.Contains(err.Error(), "timed out after 1s") {
+		t.Errorf("err = %v, want a timeout", err)
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	restartNever     = "never"
+	restartOnFailure = "on-failure"
+	restartAlways    = "always"
+
+	instanceRestartDelay = 5 * time.Second
+	maxInstanceRestarts  = 10
+	// instanceSteadyRun is how long the API must stay up before an instance
+	// gets its full restart budget back.
+	instanceSteadyRun = 10 * time.Minute
+)
+
+// InstanceConfig describes one ethminer process in mixed-backend mode.
+type InstanceConfig struct {
+	Backend       string `json:"backend"`
+	Devices       []int  `json:"devices"`
+	RestartPolicy string `json:"restartPolicy"`
+}
+
+// minerInstance is a single ethminer process bound to one GPU backend.
+// Mixed-vendor rigs run one instance per backend so NVIDIA and AMD cards can
+// hash at the same time. Fields below the blank line are guarded by the
+// owner's process mutex.
+type minerInstance struct {
+	Name          string
+	Binary        string
+	Backend       string
+	Devices       []int
+	RestartPolicy string
+
+	cmd        *exec.Cmd
+	cancel     context.CancelFunc
+	pollCancel context.CancelFunc
+	apiPort    int
+	api        *apiClient
+	stopping   bool
+	restarts   int
+	stat       Stat
+	hasStat    bool
+	// restartStreak counts restarts since the last steady run; it is what
+	// maxInstanceRestarts limits.
+	restartStreak int
+	// apiDownSince is when the API stopped answering; zero while it's healthy.
+	apiDownSince time.Time
+	// session summarizes the current process for its session record.
+	session sessionStats
+}
+
+func (m *minerInstance) running() bool {
+	return m.cmd != nil && m.cmd.Process != nil
+}
+
+type instanceSnapshot struct {
+	Name     string
+	Backend  string
+	Devices  []int
+	APIPort  int
+	Running  bool
+	PID      int
+	Restarts int
+	Stat     Stat
+	HasStat  bool
+
+	APIDownSince time.Time
+}
+
+func (m *minerInstance) snapshot() instanceSnapshot {
+	var pid int
+	if m.running() {
+		pid = m.cmd.Process.Pid
+	}
+	return instanceSnapshot{
+		Name:     m.Name,
+		Backend:  m.Backend,
+		Devices:  append([]int(nil), m.Devices...),
+		APIPort:  m.apiPort,
+		Running:  m.running(),
+		PID:      pid,
+		Restarts: m.restarts,
+		Stat:     m.stat,
+		HasStat:  m.hasStat,
+
+		APIDownSince: m.apiDownSince,
+	}
+}
+
+// planInstances turns the saved config into the set of ethminer processes to
+// launch. Single-backend configs always produce exactly one instance.
+func planInstances(cfg *Config, ethminerPath string) ([]*minerInstance, error) {
+	if cfg.Backend != backendMixed {
+		backend := resolveBackend(ethminerPath, cfg.Backend)
+		return []*minerInstance{{
+			Name:          backend,
+			Binary:        ethminerPath,
+			Backend:       backend,
+			Devices:       append([]int(nil), cfg.SelectedDevices...),
+			RestartPolicy: cfg.RestartPolicy,
+		}}, nil
+	}
+
+	var res []*minerInstance
+	seen := make(map[string]bool, len(cfg.Instances))
+	for _, ic := range cfg.Instances {
+		if ic.Backend != backendCUDA && ic.Backend != backendOpenCL {
+			continue
+		}
+		if len(ic.Devices) == 0 || seen[ic.Backend] {
+			continue
+		}
+		seen[ic.Backend] = true
+		policy := ic.RestartPolicy
+		if policy == "" {
+			policy = cfg.RestartPolicy
+		}
+		res = append(res, &minerInstance{
+			Name:          ic.Backend,
+			Binary:        ethminerPath,
+			Backend:       ic.Backend,
+			Devices:       append([]int(nil), ic.Devices...),
+			RestartPolicy: policy,
+		})
+	}
+	if len(res) == 0 {
+		return nil, errors.New("mixed mode: select at least one GPU")
+	}
+	return res, nil
+}
+
+// ethminerArgs builds the command line for one instance. The API is bound
+// read-write on loopback and protected by apiPassword.
+func ethminerArgs(cfg *Config, poolURLs []string, backend string, devices []int, apiPort int, apiPassword string) []string {
+	args := []string{"-G", "--olivetum", "--nocolor"}
+	for _, u := range poolURLs {
+		args = append(args, "-P", u)
+	}
+	args = append(args,
+		"--api-bind", fmt.Sprintf("127.0.0.1:%d", apiPort),
+		"--api-password", apiPassword,
+		"--display-interval", strconv.Itoa(cfg.DisplayInterval),
+	)
+	if backend == backendCUDA {
+		args[0] = "-U"
+	}
+	if cfg.Mode == modeStratum && cfg.ReportHashrate {
+		args = append(args, "--report-hashrate")
+	}
+	if len(devices) > 0 {
+		if backend == backendCUDA {
+			args = append(args, "--cu-devices")
+		} else {
+			args = append(args, "--cl-devices")
+		}
+		for _, idx := range devices {
+			args = append(args, strconv.Itoa(idx))
+		}
+	}
+	return args
+}
+
+func shouldRestart(policy string, exitErr error) bool {
+	switch policy {
+	case restartAlways:
+		return true
+	case restartOnFailure:
+		return exitErr != nil
+	default:
+		return false
+	}
+}
+
+func validRestartPolicy(policy string) bool {
+	return policy == restartNever || policy == restartOnFailure || policy == restartAlways
+}
+
+func backendDisplayName(backend string) string {
+	switch backend {
+	case backendCUDA:
+		return "CUDA"
+	case backendOpenCL:
+		return "OpenCL"
+	default:
+		return strings.ToUpper(backend)
+	}
+}
+
+// dedupeMixedDevices drops OpenCL devices that are also visible through CUDA.
+// NVIDIA cards show up in both lists and must only be driven by one process.
+func dedupeMixedDevices(cuda, opencl []Device) []Device {
+	cudaPCI := make(map[string]bool, len(cuda))
+	for _, d := range cuda {
+		cudaPCI[strings.ToLower(d.PCI)] = true
+	}
+	res := make([]Device, 0, len(cuda)+len(opencl))
+	res = append(res, cuda...)
+	for _, d := range opencl {
+		if cudaPCI[strings.ToLower(d.PCI)] {
+			continue
+		}
+		res = append(res, d)
+	}
+	return res
+}
+
+// aggregateStats combines per-instance stats into one rig-wide view. Per-GPU
+// slices are concatenated in instance order.
+func aggregateStats(stats []Stat) Stat {
+	var agg Stat
+	for i, s := range stats {
+		if i == 0 {
+			agg.Version = s.Version
+			agg.UptimeMin = s.UptimeMin
+			agg.Pool = s.Pool
+			agg.Detail = s.Detail
+			agg.Epoch = s.Epoch
+			agg.Difficulty = s.Difficulty
+		}
+		agg.Detail = agg.Detail && s.Detail
+		if s.UptimeMin < agg.UptimeMin {
+			agg.UptimeMin = s.UptimeMin
+		}
+		if agg.Pool == "" {
+			agg.Pool = s.Pool
+		}
+		agg.TotalKHs += s.TotalKHs
+		agg.Accepted += s.Accepted
+		agg.Rejected += s.Rejected
+		agg.Invalid += s.Invalid
+		agg.PoolSwitches += s.PoolSwitches
+		agg.PerGPU_KHs = append(agg.PerGPU_KHs, s.PerGPU_KHs...)
+		agg.Temps = append(agg.Temps, s.Temps...)
+		agg.Fans = append(agg.Fans, s.Fans...)
+		agg.Devices = append(agg.Devices, s.Devices...)
+		agg.Connected = agg.Connected || s.Connected
+	}
+	return agg
+}
+
+// shareRuns returns the share counters of each miner process with stats,
+// keyed by instance name and PID so a restarted process counts as a new run.
+func shareRuns(snaps []instanceSnapshot) map[string]shareCounts {
+	runs := make(map[string]shareCounts, len(snaps))
+	for _, sn := range snaps {
+		if !sn.HasStat {
+			continue
+		}
+		runs[fmt.Sprintf("%s/%d", sn.Name, sn.PID)] = shareCounts{
+			Accepted: sn.Stat.Accepted,
+			Rejected: sn.Stat.Rejected,
+			Invalid:  sn.Stat.Invalid,
+		}
+	}
+	return runs
+}
+
+func removeInstance(list []*minerInstance, inst *minerInstance) []*minerInstance {
+	for i, m := range list {
+		if m == inst {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
+
+func containsInstance(list []*minerInstance, inst *minerInstance) bool {
+	for _, m := range list {
+		if m == inst {
+			return true
+		}
+	}
+	return false
+}
+
+// listMixedDevices detects CUDA and OpenCL devices for mixed mode. A backend
+// that fails to enumerate is treated as having no devices unless both fail.
+func listMixedDevices(ethminerPath string) ([]Device, string, error) {
+	cuda, cudaOut, cudaErr := listEthminerDevices(ethminerPath, backendCUDA)
+	opencl, openclOut, openclErr := listEthminerDevices(ethminerPath, backendOpenCL)
+	out := strings.TrimSpace(cudaOut + "\n" + openclOut)
+	if cudaErr != nil && openclErr != nil {
+		return nil, out, fmt.Errorf("%v\n%v", cudaErr, openclErr)
+	}
+	return dedupeMixedDevices(cuda, opencl), out, nil
+}
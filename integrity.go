@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//go:embed assets/ethminer-sha256.txt
+var pinnedEthminerHashes string
+
+// bundledEthminerSHA256 is set at build time (-ldflags "-X main.bundledEthminerSHA256=...")
+// by packaging scripts that ship an ethminer next to the GUI.
+var bundledEthminerSHA256 string
+
+type integrityStatus int
+
+const (
+	integrityUnknown integrityStatus = iota
+	integrityPinned
+	integrityTrusted
+	integritySimulated
+)
+
+func (s integrityStatus) String() string {
+	switch s {
+	case integrityPinned:
+		return "verified"
+	case integrityTrusted:
+		return "trusted by you"
+	case integritySimulated:
+		return "simulator"
+	default:
+		return "unverified"
+	}
+}
+
+// TrustedBinary is an ethminer digest the user explicitly trusted. Path is
+// informational only; trust follows the content, not the location.
+type TrustedBinary struct {
+	SHA256 string `json:"sha256"`
+	Path   string `json:"path,omitempty"`
+}
+
+// untrustedBinaryError is returned for binaries whose digest is neither pinned
+// nor trusted. Such binaries are never executed.
+type untrustedBinaryError struct {
+	Path   string
+	SHA256 string
+}
+
+func (e *untrustedBinaryError) Error() string {
+	return fmt.Sprintf("unverified ethminer binary %s\nSHA-256: %s", e.Path, e.SHA256)
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", fmt.Errorf("ethminer binary is missing: %s", path)
+		}
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func pinnedHashes() map[string]bool {
+	res := make(map[string]bool)
+	if h := strings.ToLower(strings.TrimSpace(bundledEthminerSHA256)); h != "" {
+		res[h] = true
+	}
+	sc := bufio.NewScanner(strings.NewReader(pinnedEthminerHashes))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields[0]) == sha256.Size*2 {
+			res[strings.ToLower(fields[0])] = true
+		}
+	}
+	return res
+}
+
+// verifyEthminer hashes path and checks it against the pinned and trusted
+// digests without executing it.
+func verifyEthminer(path string, trusted []TrustedBinary) (string, integrityStatus, error) {
+	if simulatorPath != "" && path == simulatorPath {
+		return "", integritySimulated, nil
+	}
+	sum, err := fileSHA256(path)
+	if err != nil {
+		return "", integrityUnknown, err
+	}
+	if pinnedHashes()[sum] {
+		return sum, integrityPinned, nil
+	}
+	for _, t := range trusted {
+		if strings.EqualFold(t.SHA256, sum) {
+			return sum, integrityTrusted, nil
+		}
+	}
+	return sum, integrityUnknown, &untrustedBinaryError{Path: path, SHA256: sum}
+}
+
+func trustBinary(list []TrustedBinary, path, sum string) []TrustedBinary {
+	for _, t := range list {
+		if strings.EqualFold(t.SHA256, sum) {
+			return list
+		}
+	}
+	return append(list, TrustedBinary{SHA256: sum, Path: path})
+}
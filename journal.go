@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	journalFileName = "events.jsonl"
+	// maxJournalEvents is how many events the journal keeps; older ones are
+	// dropped when the app starts.
+	maxJournalEvents = 10000
+)
+
+// Journal event types.
+const (
+	journalMinerStarted   = "miner_started"
+	journalMinerStopped   = "miner_stopped"
+	journalMinerCrashed   = "miner_crashed"
+	journalAPIUp          = "api_up"
+	journalAPIDown        = "api_down"
+	journalPoolSwitched   = "pool_switched"
+	journalDevicesChanged = "devices_changed"
+	journalConfigSaved    = "config_saved"
+)
+
+var journalTypes = []string{
+	journalMinerStarted, journalMinerStopped, journalMinerCrashed,
+	journalAPIUp, journalAPIDown, journalPoolSwitched,
+	journalDevicesChanged, journalConfigSaved,
+}
+
+func journalTypeName(t string) string {
+	switch t {
+	case journalMinerStarted:
+		return "Miner started"
+	case journalMinerStopped:
+		return "Miner stopped"
+	case journalMinerCrashed:
+		return "Miner crashed"
+	case journalAPIUp:
+		return "API up"
+	case journalAPIDown:
+		return "API down"
+	case journalPoolSwitched:
+		return "Pool switched"
+	case journalDevicesChanged:
+		return "Devices changed"
+	case journalConfigSaved:
+		return "Config saved"
+	default:
+		return t
+	}
+}
+
+// journalEvent is one line of the event journal. Only the fields that
+// belong to its type are set.
+type journalEvent struct {
+	Time     time.Time `json:"time"`
+	Type     string    `json:"type"`
+	Instance string    `json:"instance,omitempty"`
+	Message  string    `json:"message"`
+	Binary   string    `json:"binary,omitempty"`
+	Args     []string  `json:"args,omitempty"`
+	PID      int       `json:"pid,omitempty"`
+	ExitCode *int      `json:"exitCode,omitempty"`
+	From     string    `json:"from,omitempty"`
+	To       string    `json:"to,omitempty"`
+	Backend  string    `json:"backend,omitempty"`
+	Devices  []string  `json:"devices,omitempty"`
+	Added    []string  `json:"added,omitempty"`
+	Removed  []string  `json:"removed,omitempty"`
+	Changed  []string  `json:"changed,omitempty"`
+}
+
+// eventJournal is the on-disk event list, oldest first.
+type eventJournal struct {
+	path string
+
+	mu     sync.Mutex
+	events []journalEvent
+}
+
+func journalPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configDirName, journalFileName), nil
+}
+
+// openJournal loads path, trimming it to the newest maxJournalEvents events.
+func openJournal(path string) (*eventJournal, error) {
+	j := &eventJournal{path: path}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(f)
+	// Start events carry the full command line.
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		var ev journalEvent
+		if json.Unmarshal(sc.Bytes(), &ev) == nil && ev.Type != "" {
+			j.events = append(j.events, ev)
+		}
+	}
+	f.Close()
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(j.events) > maxJournalEvents {
+		j.events = append([]journalEvent(nil), j.events[len(j.events)-maxJournalEvents:]...)
+		if err := j.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return j, nil
+}
+
+func (j *eventJournal) rewrite() error {
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, ev := range j.events {
+		if err = enc.Encode(ev); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
+
+// Append stores ev at the end of the journal.
+func (j *eventJournal) Append(ev journalEvent) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.events = append(j.events, ev)
+	if len(j.events) > maxJournalEvents {
+		j.events = j.events[len(j.events)-maxJournalEvents:]
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o700); err != nil {
+		return err
+	}
+	b, _ := json.Marshal(ev)
+	return appendLine(j.path, b)
+}
+
+// Events returns the stored events, oldest first.
+func (j *eventJournal) Events() []journalEvent {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]journalEvent(nil), j.events...)
+}
+
+// LastDevices returns the device list of the newest devices_changed event
+// for backend. Each backend sees a different set of GPUs, so switching
+// backends is not a change.
+func (j *eventJournal) LastDevices(backend string) ([]string, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := len(j.events) - 1; i >= 0; i-- {
+		if j.events[i].Type == journalDevicesChanged && j.events[i].Backend == backend {
+			return j.events[i].Devices, true
+		}
+	}
+	return nil, false
+}
+
+// deviceNames describes detected GPUs for the journal, e.g.
+// "cuda 0: NVIDIA GeForce RTX 3070 (01:00.0)".
+func deviceNames(list []Device) []string {
+	names := make([]string, 0, len(list))
+	for _, d := range list {
+		names = append(names, fmt.Sprintf("%s %d: %s (%s)", d.Backend, d.Index, d.Name, d.PCI))
+	}
+	return names
+}
+
+// diffStrings returns the entries only in next and only in prev.
+func diffStrings(prev, next []string) (added, removed []string) {
+	for _, s := range next {
+		if !slices.Contains(prev, s) {
+			added = append(added, s)
+		}
+	}
+	for _, s := range prev {
+		if !slices.Contains(next, s) {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// changedConfigKeys lists the top-level config.json keys whose values differ
+// between two encodings. Values are left out: some are secrets.
+func changedConfigKeys(prev, next []byte) []string {
+	var a, b map[string]json.RawMessage
+	_ = json.Unmarshal(prev, &a)
+	if json.Unmarshal(next, &b) != nil {
+		return nil
+	}
+	var keys []string
+	for k, v := range b {
+		if old, ok := a[k]; !ok || !bytes.Equal(old, v) {
+			keys = append(keys, k)
+		}
+	}
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
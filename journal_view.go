@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxJournalRows bounds the rows built for the Events tab; narrow the
+// filters to reach older events.
+const maxJournalRows = 300
+
+const journalAllTypes = "All events"
+
+// journalView is the "Events" tab: the event journal, newest first, filtered
+// by type, instance and text.
+type journalView struct {
+	events []journalEvent
+	w      fyne.Window
+
+	typeSelect *widget.Select
+	instSelect *widget.Select
+	search     *widget.Entry
+	rows       *fyne.Container
+	count      *widget.Label
+	view       fyne.CanvasObject
+}
+
+func newJournalView(events []journalEvent, w fyne.Window) *journalView {
+	v := &journalView{
+		events: append([]journalEvent(nil), events...),
+		w:      w,
+		rows:   container.NewVBox(),
+		count:  widget.NewLabel(""),
+		search: widget.NewEntry(),
+	}
+	typeOptions := []string{journalAllTypes}
+	for _, t := range journalTypes {
+		typeOptions = append(typeOptions, journalTypeName(t))
+	}
+	v.typeSelect = widget.NewSelect(typeOptions, func(string) { v.render() })
+	v.typeSelect.SetSelected(journalAllTypes)
+	v.instSelect = widget.NewSelect([]string{"All instances", backendCUDA, backendOpenCL}, func(string) { v.render() })
+	v.instSelect.SetSelected("All instances")
+	v.search.SetPlaceHolder("Search messages, args, devices…")
+	v.search.OnChanged = func(string) { v.render() }
+	v.count.TextStyle = fyne.TextStyle{Italic: true}
+
+	filters := container.NewBorder(nil, nil,
+		container.NewHBox(v.typeSelect, v.instSelect), v.count, v.search)
+	v.view = panel("Events", container.NewBorder(filters, nil, nil, nil, container.NewVScroll(v.rows)))
+	v.render()
+	return v
+}
+
+func (v *journalView) Object() fyne.CanvasObject {
+	return v.view
+}
+
+// Add shows a new event. It must be called on the UI goroutine.
+func (v *journalView) Add(ev journalEvent) {
+	v.events = append(v.events, ev)
+	if len(v.events) > maxJournalEvents {
+		v.events = v.events[len(v.events)-maxJournalEvents:]
+	}
+	v.render()
+}
+
+func (v *journalView) matches(ev journalEvent) bool {
+	if sel := v.typeSelect.Selected; sel != "" && sel != journalAllTypes && journalTypeName(ev.Type) != sel {
+		return false
+	}
+	if sel := v.instSelect.Selected; (sel == backendCUDA || sel == backendOpenCL) && ev.Instance != sel {
+		return false
+	}
+	q := strings.ToLower(strings.TrimSpace(v.search.Text))
+	if q == "" {
+		return true
+	}
+	b, _ := json.Marshal(ev)
+	return strings.Contains(strings.ToLower(string(b)), q)
+}
+
+func (v *journalView) render() {
+	var objs []fyne.CanvasObject
+	shown, total := 0, 0
+	for i := len(v.events) - 1; i >= 0; i-- {
+		ev := v.events[i]
+		if !v.matches(ev) {
+			continue
+		}
+		total++
+		if shown < maxJournalRows {
+			objs = append(objs, v.row(ev))
+			shown++
+		}
+	}
+	v.rows.Objects = objs
+	v.rows.Refresh()
+	switch {
+	case total == 0 && len(v.events) == 0:
+		v.count.SetText("No events yet")
+	case shown < total:
+		v.count.SetText(fmt.Sprintf("%d of %d events", shown, total))
+	default:
+		v.count.SetText(fmt.Sprintf("%d events", total))
+	}
+}
+
+func (v *journalView) row(ev journalEvent) fyne.CanvasObject {
+	cell := func(text string) *widget.Label {
+		l := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		l.Truncation = fyne.TextTruncateEllipsis
+		return l
+	}
+	kind := cell(journalTypeName(ev.Type))
+	switch ev.Type {
+	case journalMinerCrashed, journalAPIDown:
+		kind.Importance = widget.DangerImportance
+	case journalDevicesChanged:
+		kind.Importance = widget.WarningImportance
+	}
+	details := widget.NewButtonWithIcon("", theme.InfoIcon(), func() {
+		b, _ := json.MarshalIndent(ev, "", "  ")
+		text := widget.NewMultiLineEntry()
+		text.SetText(string(b))
+		text.Wrapping = fyne.TextWrapWord
+		text.TextStyle = fyne.TextStyle{Monospace: true}
+		d := dialog.NewCustom(journalTypeName(ev.Type), "Close", container.NewGridWrap(fyne.NewSize(620, 360), text), v.w)
+		d.Show()
+	})
+	details.Importance = widget.LowImportance
+	// Fixed-width columns keep the rows aligned; the message takes the rest.
+	column := func(width float32, l *widget.Label) fyne.CanvasObject {
+		return container.NewGridWrap(fyne.NewSize(width, l.MinSize().Height), l)
+	}
+	left := container.NewHBox(
+		column(180, cell(ev.Time.Local().Format("2006-01-02 15:04:05"))),
+		column(150, kind),
+		column(80, cell(orDash(ev.Instance))),
+	)
+	return container.NewBorder(nil, nil, left, details, cell(ev.Message))
+}
@@ -5,16 +5,19 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
 	"image/color"
 	"io"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -46,6 +49,7 @@ const (
 	backendAuto   = "auto"
 	backendCUDA   = "cuda"
 	backendOpenCL = "opencl"
+	backendMixed  = "mixed"
 )
 
 type Config struct {
@@ -59,12 +63,51 @@ type Config struct {
 	SelectedDevices []int  `json:"selectedDevices"`
 	ReportHashrate  bool   `json:"reportHashrate"`
 	DisplayInterval int    `json:"displayInterval"`
+	PollInterval    int    `json:"pollInterval"`
+	RestartPolicy   string `json:"restartPolicy"`
+
+	Instances []InstanceConfig `json:"instances,omitempty"`
+	Schedule  ScheduleConfig   `json:"schedule"`
+
+	StartOnLaunch bool `json:"startOnLaunch"`
+	StartupDelay  int  `json:"startupDelay"`
+
+	Hooks HooksConfig `json:"hooks"`
+
+	// FailoverPools are extra stratum host:port endpoints ethminer falls
+	// back to, in order, using the same wallet and worker.
+	FailoverPools []string `json:"failoverPools,omitempty"`
+
+	Farm []FarmRig `json:"farm,omitempty"`
+
+	// LANShare announces this rig on the local network and serves its
+	// aggregated stats read-only on LANPort.
+	LANShare bool   `json:"lanShare"`
+	RigName  string `json:"rigName,omitempty"`
+	LANPort  int    `json:"lanPort"`
+
+	Metrics MetricsConfig `json:"metrics"`
+	REST    RESTConfig    `json:"restApi"`
+
+	// Alerts are posted to every webhook in Webhooks.
+	Alerts   AlertsConfig    `json:"alerts"`
+	Webhooks []WebhookConfig `json:"webhooks,omitempty"`
+
+	Notifications NotificationsConfig `json:"notifications"`
+
+	Push PushConfig `json:"push"`
+	MQTT MQTTConfig `json:"mqtt"`
+
+	EthminerPath     string          `json:"ethminerPath,omitempty"`
+	EthminerBuilds   []EthminerBuild `json:"ethminerBuilds,omitempty"`
+	TrustedEthminers []TrustedBinary `json:"trustedEthminers,omitempty"`
 }
 
 type Device struct {
-	Index int
-	PCI   string
-	Name  string
+	Index   int
+	PCI     string
+	Name    string
+	Backend string
 }
 
 type Stat struct {
@@ -79,9 +122,25 @@ type Stat struct {
 	Temps        []int
 	Fans         []int
 	Pool         string
+
+	// Filled from miner_getstatdetail; Detail is false for getstat1 data.
+	Detail     bool
+	Connected  bool
+	Epoch      int
+	Difficulty float64
+	Devices    []DeviceStat
 }
 
 func main() {
+	if os.Getenv(simulatorEnv) == "1" {
+		os.Exit(runSimulator(os.Args[1:]))
+	}
+
+	simulate := flag.Bool("simulate", false, "run against a built-in simulated ethminer (no GPU needed)")
+	simCfg := defaultSimConfig()
+	registerSimFlags(flag.CommandLine, &simCfg)
+	flag.Parse()
+
 	a := app.NewWithID("org.olivetum.miner")
 	a.Settings().SetTheme(olivetumDarkTheme{})
 	w := a.NewWindow(appName)
@@ -91,7 +150,20 @@ func main() {
 
 	cfg := loadConfig()
 
-	ethminerPath, ethminerErr := findEthminer()
+	var (
+		activeMiner ethminerInfo
+		ethminerErr error
+	)
+	if *simulate {
+		activeMiner, ethminerErr = enableSimulator(simCfg)
+		w.SetTitle(appName + " (simulator)")
+	} else {
+		activeMiner, ethminerErr = resolveEthminer(cfg, cfg.TrustedEthminers)
+	}
+	ethminerPath := activeMiner.Path
+	if ethminerErr == nil && !*simulate {
+		cfg.EthminerBuilds = registerBuild(cfg.EthminerBuilds, activeMiner)
+	}
 
 	modeLabels := []string{
 		"Pool (Stratum)",
@@ -127,16 +199,19 @@ func main() {
 		"Auto (recommended)",
 		"CUDA (NVIDIA)",
 		"OpenCL (AMD/NVIDIA)",
+		"Mixed (CUDA + OpenCL)",
 	}
 	backendKeyForLabel := map[string]string{
 		backendLabels[0]: backendAuto,
 		backendLabels[1]: backendCUDA,
 		backendLabels[2]: backendOpenCL,
+		backendLabels[3]: backendMixed,
 	}
 	backendLabelForKey := map[string]string{
 		backendAuto:   backendLabels[0],
 		backendCUDA:   backendLabels[1],
 		backendOpenCL: backendLabels[2],
+		backendMixed:  backendLabels[3],
 	}
 	backendSelect := widget.NewSelect(backendLabels, nil)
 	if initial, ok := backendLabelForKey[cfg.Backend]; ok && initial != "" {
@@ -156,6 +231,10 @@ func main() {
 	hostEntry.SetText(cfg.StratumHost)
 	hostEntry.SetPlaceHolder(defaultStratumHost)
 
+	failoverEntry := widget.NewEntry()
+	failoverEntry.SetText(strings.Join(cfg.FailoverPools, ", "))
+	failoverEntry.SetPlaceHolder("optional: host:port, host:port")
+
 	portEntry := widget.NewEntry()
 	portEntry.SetText(strconv.Itoa(cfg.StratumPort))
 	portEntry.SetPlaceHolder(strconv.Itoa(defaultStratumPort))
@@ -179,6 +258,96 @@ func main() {
 	displayIntervalEntry.SetText(strconv.Itoa(cfg.DisplayInterval))
 	displayIntervalEntry.SetPlaceHolder("10")
 
+	pollIntervalEntry := widget.NewEntry()
+	pollIntervalEntry.SetText(strconv.Itoa(cfg.PollInterval))
+	pollIntervalEntry.SetPlaceHolder(strconv.Itoa(defaultPollInterval))
+
+	lanShareCheck := widget.NewCheck("Announce this rig on the LAN (read-only stats)", nil)
+	lanShareCheck.SetChecked(cfg.LANShare)
+
+	rigNameEntry := widget.NewEntry()
+	rigNameEntry.SetText(cfg.RigName)
+	rigNameEntry.SetPlaceHolder(defaultRigName())
+
+	lanPortEntry := widget.NewEntry()
+	lanPortEntry.SetText(strconv.Itoa(cfg.LANPort))
+	lanPortEntry.SetPlaceHolder(strconv.Itoa(defaultLANPort))
+
+	metricsCheck := widget.NewCheck("Serve Prometheus metrics (/metrics)", nil)
+	metricsCheck.SetChecked(cfg.Metrics.Enabled)
+
+	metricsBindEntry := widget.NewEntry()
+	metricsBindEntry.SetText(cfg.Metrics.Bind)
+	metricsBindEntry.SetPlaceHolder(defaultMetricsBind)
+
+	metricsPortEntry := widget.NewEntry()
+	metricsPortEntry.SetText(strconv.Itoa(cfg.Metrics.Port))
+	metricsPortEntry.SetPlaceHolder(strconv.Itoa(defaultMetricsPort))
+
+	restCheck := widget.NewCheck("Enable local REST API (127.0.0.1)", nil)
+	restCheck.SetChecked(cfg.REST.Enabled)
+
+	restPortEntry := widget.NewEntry()
+	restPortEntry.SetText(strconv.Itoa(cfg.REST.Port))
+	restPortEntry.SetPlaceHolder(strconv.Itoa(defaultRESTPort))
+
+	restTokenLabel := widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+	restTokenLabel.Truncation = fyne.TextTruncateEllipsis
+	copyTokenBtn := widget.NewButtonWithIcon("", theme.ContentCopyIcon(), nil)
+	newTokenBtn := widget.NewButtonWithIcon("New token", theme.ViewRefreshIcon(), nil)
+
+	// metricsFromUI reads the metrics bind address and port entries.
+	metricsFromUI := func() (MetricsConfig, error) {
+		m := MetricsConfig{Enabled: metricsCheck.Checked, Bind: strings.TrimSpace(metricsBindEntry.Text), Port: defaultMetricsPort}
+		if text := strings.TrimSpace(metricsPortEntry.Text); text != "" {
+			port, err := strconv.Atoi(text)
+			if err != nil {
+				return m, errors.New("invalid metrics port (1..65535)")
+			}
+			m.Port = port
+		}
+		return m, m.Validate()
+	}
+
+	restartLabels := []string{
+		"Never",
+		"On crash",
+		"Always",
+	}
+	restartKeyForLabel := map[string]string{
+		restartLabels[0]: restartNever,
+		restartLabels[1]: restartOnFailure,
+		restartLabels[2]: restartAlways,
+	}
+	restartLabelForKey := map[string]string{
+		restartNever:     restartLabels[0],
+		restartOnFailure: restartLabels[1],
+		restartAlways:    restartLabels[2],
+	}
+	newRestartSelect := func(policy string) *widget.Select {
+		sel := widget.NewSelect(restartLabels, nil)
+		if initial, ok := restartLabelForKey[policy]; ok {
+			sel.SetSelected(initial)
+		} else {
+			sel.SetSelected(restartLabels[0])
+		}
+		return sel
+	}
+	restartPolicyOf := func(sel *widget.Select) string {
+		if v, ok := restartKeyForLabel[strings.TrimSpace(sel.Selected)]; ok {
+			return v
+		}
+		return restartNever
+	}
+	restartSelect := newRestartSelect(cfg.RestartPolicy)
+
+	startOnLaunchCheck := widget.NewCheck("Start mining when the app launches", nil)
+	startOnLaunchCheck.SetChecked(cfg.StartOnLaunch)
+
+	startupDelayEntry := widget.NewEntry()
+	startupDelayEntry.SetText(strconv.Itoa(cfg.StartupDelay))
+	startupDelayEntry.SetPlaceHolder("0")
+
 	statusDot := canvas.NewCircle(theme.Color(theme.ColorNameDisabled))
 	statusDot.Resize(fyne.NewSize(10, 10))
 	statusDotHolder := container.NewVBox(
@@ -199,16 +368,76 @@ func main() {
 	poolValue.Wrapping = fyne.TextWrapWord
 	uptimeValue := widget.NewLabel("—")
 	backendInUseValue := widget.NewLabel("—")
-	hashrateHistory := newHashrateChart(300) // ~10 minutes at 2s polling
+	minerVersionValue := widget.NewLabel("—")
+	minerVersionValue.Wrapping = fyne.TextWrapWord
+	instancesGrid := container.NewGridWithColumns(1)
+	instancesGrid.Hide()
+
+	restartMinerBtn := widget.NewButtonWithIcon("Restart", theme.ViewRefreshIcon(), nil)
+	pauseAllBtn := widget.NewButtonWithIcon("Pause GPUs", theme.MediaPauseIcon(), nil)
+	rebootBtn := widget.NewButtonWithIcon("Reboot", theme.WarningIcon(), nil)
+	rebootBtn.Importance = widget.DangerImportance
+	var verbosityLevels []string
+	for v := minVerbosity; v <= maxVerbosity; v++ {
+		verbosityLevels = append(verbosityLevels, strconv.Itoa(v))
+	}
+	verbositySelect := widget.NewSelect(verbosityLevels, nil)
+	verbositySelect.PlaceHolder = strconv.Itoa(defaultVerbosity)
+	controlRow := container.NewHBox(
+		restartMinerBtn, pauseAllBtn, rebootBtn,
+		layout.NewSpacer(),
+		fieldLabel("Log verbosity"), verbositySelect,
+	)
+	controlRow.Hide()
+	gpusPaused := false
+	var pauseGPU func(key gpuKey, pause bool)
+	gpus := newGPUPanel(func(key gpuKey, pause bool) { pauseGPU(key, pause) })
+	var switchPool func(c poolConnection)
+	pools := newPoolList(func(c poolConnection) { switchPool(c) })
+	poolTrack := newPoolTracker()
+	hashrateHistory := newHashrateChart(300) // 300 polls: ~10 minutes at the default 2s interval
+	hashrate10mTitle := widget.NewLabelWithStyle("Hashrate (10 min)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	hashrate10mTitle.Wrapping = fyne.TextWrapOff
 	avgHashrateValue := widget.NewLabelWithStyle("Avg —", fyne.TextAlignTrailing, fyne.TextStyle{Monospace: true})
 	avgHashrateValue.Wrapping = fyne.TextWrapOff
 	avgHashrateValue.Importance = widget.MediumImportance
 
+	// The hashrate tile shows either the live chart or a range from the
+	// on-disk history.
+	var hist *historyStore
+	histDir, histErr := historyDir()
+	if histErr == nil {
+		hist, histErr = openHistory(histDir, time.Now())
+	}
+	historyChart := newHashrateChart(2)
+	historyChart.Object().Hide()
+	historyRanges := []struct {
+		label string
+		span  time.Duration
+		res   int
+	}{
+		{"Live", 0, 0},
+		{"24 hours", 24 * time.Hour, historyMinute},
+		{"7 days", 7 * 24 * time.Hour, historyHour},
+		{"30 days", 30 * 24 * time.Hour, historyHour},
+		{"1 year", 365 * 24 * time.Hour, historyDay},
+	}
+	var rangeLabels []string
+	for _, r := range historyRanges {
+		rangeLabels = append(rangeLabels, r.label)
+	}
+	historyRangeSelect := widget.NewSelect(rangeLabels, nil)
+	historyRangeSelect.SetSelected(rangeLabels[0])
+	if hist == nil {
+		historyRangeSelect.Disable()
+	}
+	showingHistory := false
+
 	modeHint := widget.NewLabel("")
 	modeHint.Wrapping = fyne.TextWrapWord
 	modeHint.TextStyle = fyne.TextStyle{Italic: true}
 
-	backendHint := widget.NewLabel("Tip: Auto uses CUDA on NVIDIA and OpenCL on AMD/Intel.")
+	backendHint := widget.NewLabel("Tip: Auto uses CUDA on NVIDIA and OpenCL on AMD/Intel. Mixed runs one miner per backend.")
 	backendHint.Wrapping = fyne.TextWrapWord
 	backendHint.TextStyle = fyne.TextStyle{Italic: true}
 
@@ -218,9 +447,10 @@ func main() {
 
 	devicesBox := container.NewVBox()
 	var (
-		devMu        sync.Mutex
-		devices      []Device
-		deviceChecks []*widget.Check
+		devMu          sync.Mutex
+		devices        []Device
+		deviceChecks   []*widget.Check
+		restartSelects map[string]*widget.Select
 	)
 
 	logBuf := newRingLogs(5000)
@@ -295,6 +525,14 @@ func main() {
 
 	refreshBtn := widget.NewButtonWithIcon("Refresh GPUs", theme.ViewRefreshIcon(), nil)
 
+	// devicesReady is closed once the first device detection finishes, so
+	// automated starts never save an empty GPU selection.
+	devicesReady := make(chan struct{})
+	var devicesReadyOnce sync.Once
+	markDevicesReady := func() {
+		devicesReadyOnce.Do(func() { close(devicesReady) })
+	}
+
 	quickPoolRow := container.NewGridWithColumns(2, hostEntry, portEntry)
 	modeRow := formRow("Mode", modeSelect)
 	walletRow := formRow("Wallet", walletEntry)
@@ -335,19 +573,78 @@ func main() {
 	}
 	applyModeUI()
 
+	// handleEthminerErr reports a binary problem. Unverified binaries get a
+	// trust prompt, and retry runs once the user trusts them.
+	var handleEthminerErr func(err error, retry func())
+
+	var journal *eventJournal
+	jPath, journalErr := journalPath()
+	if journalErr == nil {
+		journal, journalErr = openJournal(jPath)
+	}
+	var (
+		pastEvents []journalEvent
+		// knownDevices holds the GPU list last journaled per backend;
+		// guarded by devMu once device scans run.
+		knownDevices = make(map[string][]string)
+	)
+	if journalErr != nil {
+		appendLog(fmt.Sprintf("[journal] not saved: %v\n", journalErr))
+	} else {
+		pastEvents = journal.Events()
+	}
+	journalTab := newJournalView(pastEvents, w)
+	// recordEvent appends ev to the event journal and the Events tab.
+	recordEvent := func(ev journalEvent) {
+		if ev.Time.IsZero() {
+			ev.Time = time.Now()
+		}
+		if journal != nil {
+			if err := journal.Append(ev); err != nil {
+				appendLog(fmt.Sprintf("[journal] %v\n", err))
+			}
+		}
+		fyne.Do(func() { journalTab.Add(ev) })
+	}
+	// persistConfig saves cfg and journals which settings changed.
+	lastSavedConfig, _ := json.Marshal(cfg)
+	persistConfig := func() error {
+		b, _ := json.Marshal(cfg)
+		if err := saveConfig(cfg); err != nil {
+			return err
+		}
+		if changed := changedConfigKeys(lastSavedConfig, b); len(changed) > 0 {
+			recordEvent(journalEvent{Type: journalConfigSaved, Message: "Changed: " + strings.Join(changed, ", "), Changed: changed})
+		}
+		lastSavedConfig = b
+		return nil
+	}
+
 	refreshDevices := func() {
 		if ethminerErr != nil {
-			dialog.ShowError(fmt.Errorf("ethminer not found: %w", ethminerErr), w)
+			handleEthminerErr(ethminerErr, nil)
 			return
 		}
 		refreshBtn.Disable()
 		devicesBox.Objects = []fyne.CanvasObject{widget.NewLabel("Detecting GPUs...")}
 		devicesBox.Refresh()
 
+		ethminerPath := ethminerPath
 		go func() {
 			backendSelection := selectedBackend()
-			backend := resolveBackend(ethminerPath, backendSelection)
-			list, out, err := listEthminerDevices(ethminerPath, backend)
+			var (
+				list    []Device
+				out     string
+				err     error
+				backend string
+			)
+			if backendSelection == backendMixed {
+				backend = backendMixed
+				list, out, err = listMixedDevices(ethminerPath)
+			} else {
+				backend = resolveBackend(ethminerPath, backendSelection)
+				list, out, err = listEthminerDevices(ethminerPath, backend)
+			}
 			if err != nil {
 				appendLog(fmt.Sprintf("[devices] %v\n", err))
 				fyne.Do(func() {
@@ -361,22 +658,34 @@ func main() {
 					}
 					devicesBox.Refresh()
 					refreshBtn.Enable()
+					markDevicesReady()
 				})
 				return
 			}
 
-			selected := make(map[int]bool, len(cfg.SelectedDevices))
-			for _, idx := range cfg.SelectedDevices {
-				selected[idx] = true
+			selected := make(map[string]bool, len(cfg.SelectedDevices))
+			policies := make(map[string]string, len(cfg.Instances))
+			if backend == backendMixed {
+				for _, ic := range cfg.Instances {
+					policies[ic.Backend] = ic.RestartPolicy
+					for _, idx := range ic.Devices {
+						selected[fmt.Sprintf("%s/%d", ic.Backend, idx)] = true
+					}
+				}
+			} else {
+				for _, idx := range cfg.SelectedDevices {
+					selected[fmt.Sprintf("%s/%d", backend, idx)] = true
+				}
 			}
 			var (
 				newObjects []fyne.CanvasObject
 				newChecks  []*widget.Check
+				newSelects map[string]*widget.Select
 			)
 			if len(list) == 0 {
-				backendName := "OpenCL"
-				if backend == backendCUDA {
-					backendName = "CUDA"
+				backendName := backendDisplayName(backend)
+				if backend == backendMixed {
+					backendName = "CUDA or OpenCL"
 				}
 				if strings.TrimSpace(out) != "" {
 					appendLog(fmt.Sprintf("[devices] %s --list-devices output:\n%s\n", backendName, strings.TrimSpace(out)))
@@ -387,43 +696,169 @@ func main() {
 			} else {
 				newObjects = make([]fyne.CanvasObject, 0, len(list))
 				newChecks = make([]*widget.Check, 0, len(list))
+				if backend == backendMixed {
+					newSelects = make(map[string]*widget.Select, 2)
+				}
 				for _, d := range list {
 					d := d
-					check := widget.NewCheck(fmt.Sprintf("[%d] %s (%s)", d.Index, d.Name, d.PCI), nil)
-					check.SetChecked(selected[d.Index])
+					if backend == backendMixed && newSelects[d.Backend] == nil {
+						policy := policies[d.Backend]
+						if policy == "" {
+							policy = cfg.RestartPolicy
+						}
+						sel := newRestartSelect(policy)
+						newSelects[d.Backend] = sel
+						newObjects = append(newObjects, container.NewBorder(nil, nil,
+							fieldLabel(backendDisplayName(d.Backend)), nil,
+							formRow("Restart", sel)))
+					}
+					text := fmt.Sprintf("[%d] %s (%s)", d.Index, d.Name, d.PCI)
+					if backend == backendMixed {
+						text = fmt.Sprintf("[%s %d] %s (%s)", backendDisplayName(d.Backend), d.Index, d.Name, d.PCI)
+					}
+					check := widget.NewCheck(text, nil)
+					check.SetChecked(selected[fmt.Sprintf("%s/%d", d.Backend, d.Index)])
 					newChecks = append(newChecks, check)
 					newObjects = append(newObjects, check)
 				}
 			}
 
+			names := deviceNames(list)
 			devMu.Lock()
 			devices = list
 			deviceChecks = newChecks
+			restartSelects = newSelects
+			// Scans can overlap; compare and update under the same lock so
+			// each change is journaled once.
+			known, ok := knownDevices[backend]
+			if !ok && journal != nil {
+				known, ok = journal.LastDevices(backend)
+			}
+			devicesChanged := !ok || !slices.Equal(names, known)
+			added, removed := diffStrings(known, names)
+			knownDevices[backend] = names
 			devMu.Unlock()
 
+			if devicesChanged {
+				msg := fmt.Sprintf("%d GPU(s) detected", len(names))
+				if len(added) > 0 {
+					msg += "; added " + strings.Join(added, ", ")
+				}
+				if len(removed) > 0 {
+					msg += "; removed " + strings.Join(removed, ", ")
+				}
+				recordEvent(journalEvent{Type: journalDevicesChanged, Message: msg, Backend: backend, Devices: names, Added: added, Removed: removed})
+			}
+
 			fyne.Do(func() {
 				if backendSelection == backendAuto {
 					backendResolvedHint.SetText(fmt.Sprintf("Auto resolved to: %s", strings.ToUpper(backend)))
+				} else if backendSelection == backendMixed {
+					backendResolvedHint.SetText("Mixed: NVIDIA cards run on CUDA, the rest on OpenCL.")
 				} else {
 					backendResolvedHint.SetText("")
 				}
 				devicesBox.Objects = newObjects
 				devicesBox.Refresh()
 				refreshBtn.Enable()
+				markDevicesReady()
 			})
 		}()
 	}
 	refreshBtn.OnTapped = refreshDevices
 	backendSelect.OnChanged = func(_ string) { refreshDevices() }
 
+	// logHistoryErr reports history write failures once until they change,
+	// so a full disk doesn't flood the log every minute.
+	var (
+		historyErrMu   sync.Mutex
+		lastHistoryErr string
+	)
+	logHistoryErr := func(err error) {
+		text := ""
+		if err != nil {
+			text = err.Error()
+		}
+		historyErrMu.Lock()
+		defer historyErrMu.Unlock()
+		if text != "" && text != lastHistoryErr {
+			appendLog(fmt.Sprintf("[history] %s\n", text))
+		}
+		lastHistoryErr = text
+	}
+	if histErr != nil {
+		appendLog(fmt.Sprintf("[history] disabled: %v\n", histErr))
+	}
+
+	var sessions *sessionLog
+	sessPath, sessErr := sessionsPath()
+	if sessErr == nil {
+		sessions, sessErr = openSessionLog(sessPath)
+	}
+	var pastSessions []sessionRecord
+	if sessErr != nil {
+		appendLog(fmt.Sprintf("[session] not saved: %v\n", sessErr))
+	} else {
+		pastSessions = sessions.Records()
+	}
+	sessionsTab := newSessionsView(pastSessions)
+	// recordSession stores the summary of a miner process that just exited.
+	recordSession := func(r sessionRecord, logLine func(string)) {
+		logLine(fmt.Sprintf("[session] %s, avg %.2f MH/s, peak %.2f MH/s, A %d | R %d | I %d, %s\n",
+			formatSessionDuration(r.Duration()), r.AvgMHs, r.PeakMHs, r.Accepted, r.Rejected, r.Invalid, r.ExitReason))
+		if sessions != nil {
+			if err := sessions.Append(r); err != nil {
+				logLine(fmt.Sprintf("[session] %v\n", err))
+			}
+		}
+		fyne.Do(func() { sessionsTab.Add(r) })
+	}
+
+	rigName := func() string {
+		if cfg.RigName != "" {
+			return cfg.RigName
+		}
+		return defaultRigName()
+	}
+	webhooks := newWebhookNotifier(rigName, func(format string, args ...any) {
+		appendLog(fmt.Sprintf(format, args...))
+	})
+	webhooks.Configure(cfg.Webhooks)
+	alerts := newAlertMonitor(cfg.Alerts)
+	// emitAlert logs ev, posts it to the webhooks and shows a desktop
+	// notification if enabled for its kind.
+	emitAlert := func(ev alertEvent) {
+		if ev.Time.IsZero() {
+			ev.Time = time.Now()
+		}
+		appendLog(fmt.Sprintf("[alert] %s\n", ev.Message))
+		webhooks.Notify(ev)
+		if cfg.Notifications.enabled(ev.Kind) {
+			a.SendNotification(desktopNotification(ev))
+		}
+	}
+
 	var (
 		procMu      sync.Mutex
-		minerCmd    *exec.Cmd
-		minerCtx    context.Context
-		minerCancel context.CancelFunc
-		apiPort     int
-		pollCancel  context.CancelFunc
+		instances   []*minerInstance
+		statsCancel context.CancelFunc
+	)
+	// startCancel is set while a start waits for its pre-start hook; calling
+	// it abandons that start. Guarded by procMu.
+	var startCancel context.CancelFunc
+	instanceLabels := map[string]*widget.Label{}
+
+	// lanStat is the latest aggregated stat served to LAN peers.
+	var (
+		lanMu     sync.Mutex
+		lanStat   Stat
+		lanStatOK bool
 	)
+	setLANStat := func(s Stat, ok bool) {
+		lanMu.Lock()
+		lanStat, lanStatOK = s, ok
+		lanMu.Unlock()
+	}
 
 	var startBtn *widget.Button
 	var stopBtn *widget.Button
@@ -433,6 +868,7 @@ func main() {
 			statusValue.SetText("Running")
 			statusDot.FillColor = theme.Color(theme.ColorNamePrimary)
 			statusDot.Refresh()
+			controlRow.Show()
 			if startBtn != nil {
 				startBtn.Disable()
 			}
@@ -449,8 +885,20 @@ func main() {
 			poolValue.SetText("—")
 			uptimeValue.SetText("—")
 			backendInUseValue.SetText("—")
+			instancesGrid.Objects = nil
+			instancesGrid.Hide()
+			controlRow.Hide()
+			gpus.Reset()
+			pools.Reset()
+			poolTrack.Reset()
+			gpusPaused = false
+			pauseAllBtn.SetText("Pause GPUs")
+			pauseAllBtn.SetIcon(theme.MediaPauseIcon())
+			verbositySelect.ClearSelected()
 			hashrateHistory.Reset()
-			avgHashrateValue.SetText("Avg —")
+			if !showingHistory {
+				avgHashrateValue.SetText("Avg —")
+			}
 			if startBtn != nil {
 				startBtn.Enable()
 			}
@@ -460,6 +908,46 @@ func main() {
 		}
 	}
 
+	// collectSelection returns the checked devices. Mixed mode groups them per
+	// backend; otherwise the previous per-backend selection is kept as is.
+	collectSelection := func() ([]int, []InstanceConfig) {
+		devMu.Lock()
+		defer devMu.Unlock()
+		if selectedBackend() != backendMixed {
+			var selected []int
+			for i, c := range deviceChecks {
+				if c.Checked && i < len(devices) {
+					selected = append(selected, devices[i].Index)
+				}
+			}
+			return selected, cfg.Instances
+		}
+		var instances []InstanceConfig
+		for _, backend := range []string{backendCUDA, backendOpenCL} {
+			ic := InstanceConfig{Backend: backend, RestartPolicy: restartPolicyOf(restartSelect)}
+			if sel := restartSelects[backend]; sel != nil {
+				ic.RestartPolicy = restartPolicyOf(sel)
+			}
+			for i, c := range deviceChecks {
+				if c.Checked && i < len(devices) && devices[i].Backend == backend {
+					ic.Devices = append(ic.Devices, devices[i].Index)
+				}
+			}
+			if len(ic.Devices) > 0 {
+				instances = append(instances, ic)
+			}
+		}
+		return cfg.SelectedDevices, instances
+	}
+
+	// applyMetrics restarts the /metrics server from cfg.Metrics; it is set
+	// once the run state it reports can be collected. metricsApplied is the
+	// config the server was last started with.
+	var (
+		applyMetrics   func()
+		metricsApplied MetricsConfig
+	)
+
 	saveFromUI := func() error {
 		mode := selectedMode()
 		var err error
@@ -523,14 +1011,53 @@ func main() {
 			}
 		}
 
-		var selected []int
-		devMu.Lock()
-		for i, c := range deviceChecks {
-			if c.Checked && i < len(devices) {
-				selected = append(selected, devices[i].Index)
+		failover, err := parseFailoverPools(failoverEntry.Text)
+		if err != nil {
+			return err
+		}
+
+		pollIntv := defaultPollInterval
+		if text := strings.TrimSpace(pollIntervalEntry.Text); text != "" {
+			pollIntv, err = strconv.Atoi(text)
+			if err != nil || pollIntv < 1 || pollIntv > 60 {
+				return errors.New("invalid stats poll interval (1..60)")
+			}
+		}
+
+		startupDelay := 0
+		if text := strings.TrimSpace(startupDelayEntry.Text); text != "" {
+			startupDelay, err = strconv.Atoi(text)
+			if err != nil || startupDelay < 0 || startupDelay > 600 {
+				return errors.New("invalid startup delay (0..600)")
+			}
+		}
+
+		metrics, err := metricsFromUI()
+		if err != nil {
+			return err
+		}
+
+		restPort := defaultRESTPort
+		if text := strings.TrimSpace(restPortEntry.Text); text != "" {
+			restPort, err = strconv.Atoi(text)
+			if err != nil || restPort < 1 || restPort > 65535 {
+				return errors.New("invalid REST API port (1..65535)")
+			}
+		}
+
+		lanPort := defaultLANPort
+		if text := strings.TrimSpace(lanPortEntry.Text); text != "" {
+			lanPort, err = strconv.Atoi(text)
+			if err != nil || lanPort < 1 || lanPort > 65535 {
+				return errors.New("invalid LAN stats port (1..65535)")
 			}
 		}
-		devMu.Unlock()
+
+		selected, instances := collectSelection()
+		restartPolicy := restartPolicyOf(restartSelect)
+		if selectedBackend() == backendMixed && len(instances) == 0 {
+			return errors.New("mixed mode: select at least one GPU")
+		}
 
 		cfg.Mode = mode
 		cfg.Backend = selectedBackend()
@@ -540,9 +1067,25 @@ func main() {
 		cfg.WalletAddress = strings.ToLower(wallet)
 		cfg.WorkerName = worker
 		cfg.SelectedDevices = selected
+		cfg.Instances = instances
 		cfg.ReportHashrate = reportHashrateCheck.Checked
 		cfg.DisplayInterval = displayIntv
-		return saveConfig(cfg)
+		cfg.PollInterval = pollIntv
+		cfg.FailoverPools = failover
+		cfg.RestartPolicy = restartPolicy
+		cfg.StartOnLaunch = startOnLaunchCheck.Checked
+		cfg.StartupDelay = startupDelay
+		cfg.RigName = strings.TrimSpace(rigNameEntry.Text)
+		cfg.LANPort = lanPort
+		cfg.Metrics = metrics
+		cfg.REST.Port = restPort
+		if err := persistConfig(); err != nil {
+			return err
+		}
+		if applyMetrics != nil && cfg.Metrics != metricsApplied {
+			applyMetrics()
+		}
+		return nil
 	}
 
 	saveDraftFromUI := func() {
@@ -581,176 +1124,625 @@ func main() {
 			cfg.DisplayInterval = 10
 		}
 
-		var selected []int
-		devMu.Lock()
-		for i, c := range deviceChecks {
-			if c.Checked && i < len(devices) {
-				selected = append(selected, devices[i].Index)
-			}
+		if pi, err := strconv.Atoi(strings.TrimSpace(pollIntervalEntry.Text)); err == nil && pi >= 1 && pi <= 60 {
+			cfg.PollInterval = pi
+		}
+		if failover, err := parseFailoverPools(failoverEntry.Text); err == nil {
+			cfg.FailoverPools = failover
 		}
-		devMu.Unlock()
+		cfg.RigName = strings.TrimSpace(rigNameEntry.Text)
+		if lp, err := strconv.Atoi(strings.TrimSpace(lanPortEntry.Text)); err == nil && lp >= 1 && lp <= 65535 {
+			cfg.LANPort = lp
+		}
+		if metrics, err := metricsFromUI(); err == nil {
+			cfg.Metrics = metrics
+		}
+		if rp, err := strconv.Atoi(strings.TrimSpace(restPortEntry.Text)); err == nil && rp >= 1 && rp <= 65535 {
+			cfg.REST.Port = rp
+		}
+
+		selected, instances := collectSelection()
 		cfg.SelectedDevices = selected
+		cfg.Instances = instances
+		cfg.RestartPolicy = restartPolicyOf(restartSelect)
+		cfg.StartOnLaunch = startOnLaunchCheck.Checked
+		if delay, err := strconv.Atoi(strings.TrimSpace(startupDelayEntry.Text)); err == nil && delay >= 0 && delay <= 600 {
+			cfg.StartupDelay = delay
+		}
 
-		_ = saveConfig(cfg)
+		_ = persistConfig()
 	}
 
-	startMiner := func() {
-		if ethminerErr != nil {
-			dialog.ShowError(fmt.Errorf("ethminer not found: %w", ethminerErr), w)
-			return
+	// finishRunLocked resets the dashboard once the last instance is gone.
+	// Caller must hold procMu.
+	finishRunLocked := func() {
+		if statsCancel != nil {
+			statsCancel()
+			statsCancel = nil
 		}
-		if err := saveFromUI(); err != nil {
-			dialog.ShowError(err, w)
-			return
+		setLANStat(Stat{}, false)
+		if hist != nil {
+			logHistoryErr(hist.Flush(time.Now()))
 		}
+		fyne.Do(func() { setRunningUI(false) })
+	}
 
-		procMu.Lock()
-		defer procMu.Unlock()
-		if minerCmd != nil && minerCmd.Process != nil {
-			dialog.ShowInformation(appName, "Miner already running", w)
-			return
-		}
+	// stopInstanceLocked asks one ethminer to exit. Caller must hold procMu.
+	stopInstanceLocked := func(inst *minerInstance) {
+		cmd := inst.cmd
+		proc := cmd.Process
+		_ = proc.Signal(os.Interrupt)
+		// Fallback hard kill after a short grace (only if it's still the same process).
+		go func(cmd *exec.Cmd, p *os.Process) {
+			time.Sleep(5 * time.Second)
+			procMu.Lock()
+			still := inst.cmd == cmd
+			procMu.Unlock()
+			if still {
+				_ = p.Kill()
+			}
+		}(cmd, proc)
+	}
 
+	// launchInstance starts (or restarts) one ethminer process and its stats
+	// poller. Caller must hold procMu.
+	var launchInstance func(inst *minerInstance, logLine func(string)) error
+	launchInstance = func(inst *minerInstance, logLine func(string)) error {
 		port, err := pickFreePort()
 		if err != nil {
-			dialog.ShowError(err, w)
-			return
+			return err
 		}
-		apiPort = port
-
-		poolURL, err := buildPoolURL(cfg)
+		poolURLs, err := buildPoolURLs(cfg)
 		if err != nil {
-			dialog.ShowError(err, w)
-			return
-		}
-
-		backendSelection := selectedBackend()
-		backend := resolveBackend(ethminerPath, backendSelection)
-		args := []string{
-			"-G",
-			"--olivetum",
-			"--nocolor",
-			"-P", poolURL,
-			"--api-bind", fmt.Sprintf("127.0.0.1:-%d", apiPort),
-			"--display-interval", strconv.Itoa(cfg.DisplayInterval),
-		}
-		if backend == backendCUDA {
-			args[0] = "-U"
-		}
-		if cfg.Mode == modeStratum && cfg.ReportHashrate {
-			args = append(args, "--report-hashrate")
+			return err
 		}
-		if len(cfg.SelectedDevices) > 0 {
-			if backend == backendCUDA {
-				args = append(args, "--cu-devices")
-			} else {
-				args = append(args, "--cl-devices")
-			}
-			for _, idx := range cfg.SelectedDevices {
-				args = append(args, strconv.Itoa(idx))
-			}
+		poolURL := poolURLs[0]
+		apiPassword, err := newAPIPassword()
+		if err != nil {
+			return err
 		}
+		args := ethminerArgs(cfg, poolURLs, inst.Backend, inst.Devices, port, apiPassword)
+		hooks, hc := cfg.Hooks, instanceHookContext(cfg, inst, poolURL)
+		trusted := cfg.TrustedEthminers
+		pollEvery := time.Duration(cfg.PollInterval) * time.Second
 
-		minerCtx, minerCancel = context.WithCancel(context.Background())
-		cmd := exec.CommandContext(minerCtx, ethminerPath, args...)
+		ctx, cancel := context.WithCancel(context.Background())
+		cmd := exec.CommandContext(ctx, inst.Binary, args...)
 		configureChildProcess(cmd)
 		cmd.Env = append(os.Environ(), "LC_ALL=C")
 
 		stdout, _ := cmd.StdoutPipe()
 		stderr, _ := cmd.StderrPipe()
 
-		resetLog()
-		appendLog(fmt.Sprintf("Starting: %s %s\n\n", ethminerPath, strings.Join(args, " ")))
+		cmdLine := strings.ReplaceAll(strings.Join(args, " "), apiPassword, "********")
+		logLine(fmt.Sprintf("Starting: %s %s\n\n", inst.Binary, cmdLine))
 
 		if err := cmd.Start(); err != nil {
-			minerCancel()
-			minerCtx = nil
-			minerCancel = nil
-			dialog.ShowError(err, w)
-			return
+			cancel()
+			return err
 		}
-		minerCmd = cmd
-		setRunningUI(true)
-		if backendSelection == backendAuto {
-			backendInUseValue.SetText(fmt.Sprintf("Auto → %s", strings.ToUpper(backend)))
-		} else {
-			backendInUseValue.SetText(strings.ToUpper(backend))
+		inst.cmd = cmd
+		inst.cancel = cancel
+		inst.apiPort = port
+		inst.hasStat = false
+		inst.apiDownSince = time.Time{}
+		inst.api = newAPIClient("127.0.0.1", port, apiPassword)
+		inst.session = sessionStats{Start: time.Now()}
+		mode := cfg.Mode
+		pid := cmd.Process.Pid
+		journalArgs := make([]string, len(args))
+		for i, arg := range args {
+			journalArgs[i] = strings.ReplaceAll(arg, apiPassword, "********")
 		}
+		recordEvent(journalEvent{
+			Type:     journalMinerStarted,
+			Instance: inst.Name,
+			Message:  fmt.Sprintf("Started %s (pid %d)", filepath.Base(inst.Binary), pid),
+			Binary:   inst.Binary,
+			Args:     journalArgs,
+			PID:      pid,
+		})
 
-		go streamLines(stdout, appendLog)
-		go streamLines(stderr, appendLog)
-
-		ctx, cancel := context.WithCancel(context.Background())
-		pollCancel = cancel
-		go pollStats(ctx, "127.0.0.1", apiPort, func(s Stat) {
-			hs := fmt.Sprintf("%.2f MH/s", float64(s.TotalKHs)/1000.0)
-			fyne.Do(func() {
-				hashrateValue.Text = hs
-				hashrateValue.Refresh()
-				hashrateHistory.Add(float64(s.TotalKHs) / 1000.0)
-				if avg, ok := hashrateHistory.Average(); ok {
-					avgHashrateValue.SetText(fmt.Sprintf("Avg %.2f MH/s", avg))
-				} else {
-					avgHashrateValue.SetText("Avg —")
+		go streamLines(stdout, logLine)
+		go streamLines(stderr, logLine)
+
+		pollCtx, pollCancel := context.WithCancel(context.Background())
+		inst.pollCancel = pollCancel
+		// apiUp, apiErr and upSince are only touched by the poller's
+		// callbacks. The journal records outages after the API first
+		// answered, not the wait for the DAG.
+		var (
+			apiUp   bool
+			apiErr  error
+			upSince time.Time
+		)
+		go pollStats(pollCtx, inst.api, pollEvery, func(s Stat) {
+			now := time.Now()
+			if upSince.IsZero() {
+				upSince = now
+			}
+			steady := false
+			procMu.Lock()
+			if inst.cmd == cmd {
+				inst.stat = s
+				inst.hasStat = true
+				inst.session.Add(s)
+				if inst.restartStreak > 0 && now.Sub(upSince) >= instanceSteadyRun {
+					inst.restartStreak = 0
+					steady = true
 				}
-				sharesValue.SetText(fmt.Sprintf("Accepted %d | Rejected %d | Invalid %d", s.Accepted, s.Rejected, s.Invalid))
-				poolValue.SetText(s.Pool)
-				uptimeValue.SetText(fmt.Sprintf("%d min", s.UptimeMin))
-			})
+			}
+			procMu.Unlock()
+			if steady {
+				logLine(fmt.Sprintf("[restart] running steadily for %s; restart limit reset\n", instanceSteadyRun))
+			}
+			if !apiUp {
+				apiUp = true
+				recordEvent(journalEvent{Type: journalAPIUp, Instance: inst.Name, Message: fmt.Sprintf("API answering on port %d", port), PID: pid})
+			}
 		}, func(err error) {
-			// Only show transient failures in log; API might not be ready yet.
-			appendLog(fmt.Sprintf("[api] %v\n", err))
+			// The API is not up until the DAG is built; repeats of the same
+			// error are suppressed and shown as an outage timer instead.
+			apiErr = err
+			logLine(fmt.Sprintf("[api] %v\n", err))
+		}, func(since time.Time) {
+			if !since.IsZero() {
+				upSince = time.Time{}
+			}
+			if !since.IsZero() && apiUp {
+				apiUp = false
+				recordEvent(journalEvent{Type: journalAPIDown, Instance: inst.Name, Message: fmt.Sprintf("API unreachable: %v", apiErr), PID: pid})
+			}
+			procMu.Lock()
+			prev := inst.apiDownSince
+			if inst.cmd == cmd {
+				inst.apiDownSince = since
+			}
+			procMu.Unlock()
+			if since.IsZero() && !prev.IsZero() {
+				logLine(fmt.Sprintf("[api] reachable again after %s\n", time.Since(prev).Round(time.Second)))
+			}
 		})
 
 		go func() {
 			err := cmd.Wait()
 			procMu.Lock()
-			minerCmd = nil
-			if pollCancel != nil {
-				pollCancel()
-				pollCancel = nil
-			}
-			if minerCancel != nil {
-				minerCancel()
-				minerCancel = nil
-			}
+			session := newSessionRecord(inst, mode, poolURL, time.Now(), err)
+			inst.cmd = nil
+			inst.hasStat = false
+			inst.apiDownSince = time.Time{}
+			inst.api = nil
+			pollCancel()
+			cancel()
+			crashed := !inst.stopping && err != nil
 			procMu.Unlock()
 
-			fyne.Do(func() { setRunningUI(false) })
 			if err != nil && !errors.Is(err, context.Canceled) {
-				appendLog(fmt.Sprintf("\n[exit] %v\n", err))
+				logLine(fmt.Sprintf("\n[exit] %v\n", err))
 			} else {
-				appendLog("\n[exit] miner stopped\n")
+				logLine("\n[exit] miner stopped\n")
+			}
+			recordSession(session, logLine)
+			code := exitCodeOf(err)
+			exitEv := journalEvent{
+				Type:     journalMinerStopped,
+				Instance: inst.Name,
+				Message:  fmt.Sprintf("Miner %s (exit code %d)", session.ExitReason, code),
+				PID:      pid,
+				ExitCode: &code,
+			}
+			if crashed {
+				exitEv.Type = journalMinerCrashed
+				exitEv.Message = fmt.Sprintf("Crashed with exit code %d: %v", code, err)
+			}
+			recordEvent(exitEv)
+
+			// Hooks run before the instance is released so a post-exit reset
+			// finishes before a restart or before the app is allowed to quit.
+			hc.ExitCode = &code
+			_ = runHook(context.Background(), hooks, hookPostExit, hc, logLine)
+			if crashed {
+				emitAlert(alertEvent{
+					Kind:    alertCrash,
+					Message: fmt.Sprintf("%s miner exited unexpectedly: %v", backendDisplayName(inst.Backend), err),
+					Details: map[string]any{"instance": inst.Name, "exitCode": code},
+				})
+				_ = runHook(context.Background(), hooks, hookOnCrash, hc, logLine)
 			}
-		}()
-	}
 
-	stopMiner := func() {
-		procMu.Lock()
-		defer procMu.Unlock()
-		if minerCmd == nil || minerCmd.Process == nil {
-			return
-		}
-		appendLog("\nStopping miner...\n")
-		cmd := minerCmd
-		proc := minerCmd.Process
-		_ = proc.Signal(os.Interrupt)
-		// Fallback hard kill after a short grace (only if it's still the same process).
-		go func(cmd *exec.Cmd, p *os.Process) {
-			time.Sleep(5 * time.Second)
 			procMu.Lock()
-			still := minerCmd == cmd
+			restart := !inst.stopping && shouldRestart(inst.RestartPolicy, err) && inst.restartStreak < maxInstanceRestarts
+			if !restart && containsInstance(instances, inst) {
+				instances = removeInstance(instances, inst)
+				if len(instances) == 0 {
+					finishRunLocked()
+				}
+			}
 			procMu.Unlock()
-			if still {
-				_ = p.Kill()
+			if !restart {
+				return
 			}
-		}(cmd, proc)
+
+			logLine(fmt.Sprintf("[restart] restarting in %s (attempt %d/%d)\n", instanceRestartDelay, inst.restartStreak+1, maxInstanceRestarts))
+			time.AfterFunc(instanceRestartDelay, func() {
+				_, _, verifyErr := verifyEthminer(inst.Binary, trusted)
+				procMu.Lock()
+				defer procMu.Unlock()
+				if inst.stopping || !containsInstance(instances, inst) {
+					return
+				}
+				if verifyErr != nil {
+					logLine(fmt.Sprintf("[restart] %v\n", verifyErr))
+					instances = removeInstance(instances, inst)
+					if len(instances) == 0 {
+						finishRunLocked()
+					}
+					return
+				}
+				inst.restarts++
+				inst.restartStreak++
+				if err := launchInstance(inst, logLine); err != nil {
+					logLine(fmt.Sprintf("[restart] %v\n", err))
+					instances = removeInstance(instances, inst)
+					if len(instances) == 0 {
+						finishRunLocked()
+					}
+					return
+				}
+				emitAlert(alertEvent{
+					Kind:    alertRestart,
+					Message: fmt.Sprintf("%s miner restarted (attempt %d/%d)", backendDisplayName(inst.Backend), inst.restartStreak, maxInstanceRestarts),
+					Details: map[string]any{"instance": inst.Name, "restarts": inst.restarts},
+				})
+			})
+		}()
+		return nil
 	}
 
-	startBtn = widget.NewButtonWithIcon("Start mining", theme.MediaPlayIcon(), startMiner)
-	startBtn.Importance = widget.HighImportance
-	stopBtn = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), stopMiner)
-	stopBtn.Importance = widget.DangerImportance
+	formatInstance := func(sn instanceSnapshot) string {
+		switch {
+		case !sn.Running:
+			return fmt.Sprintf("Restarting... (restarts %d)", sn.Restarts)
+		case !sn.APIDownSince.IsZero():
+			return "API unreachable for " + time.Since(sn.APIDownSince).Round(time.Second).String()
+		case !sn.HasStat:
+			return "Waiting for API..."
+		}
+		return fmt.Sprintf("%.2f MH/s · %d GPU(s)\nA %d | R %d | I %d · restarts %d",
+			float64(sn.Stat.TotalKHs)/1000.0, len(sn.Stat.PerGPU_KHs),
+			sn.Stat.Accepted, sn.Stat.Rejected, sn.Stat.Invalid, sn.Restarts)
+	}
+
+	// watchStats aggregates the per-instance stats into the dashboard.
+	watchStats := func(ctx context.Context) {
+		ticker := time.NewTicker(time.Duration(cfg.PollInterval) * time.Second)
+		defer ticker.Stop()
+		alerts.Reset()
+		solo := cfg.Mode != modeStratum
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			procMu.Lock()
+			snaps := make([]instanceSnapshot, 0, len(instances))
+			var api *apiClient
+			for _, inst := range instances {
+				snaps = append(snaps, inst.snapshot())
+				if api == nil && inst.running() {
+					api = inst.api
+				}
+			}
+			procMu.Unlock()
+
+			// All instances mine against the same pools, so the first one
+			// stands in for the rig.
+			var conns []poolConnection
+			if api != nil {
+				if c, err := api.Connections(); err == nil {
+					conns = c
+					if from, to, manual, changed := poolTrack.Observe(c); changed {
+						kind := "failover"
+						if manual {
+							kind = "manual switch"
+						}
+						fromHost, toHost := poolHost(connectionURI(c, from)), poolHost(connectionURI(c, to))
+						appendLog(fmt.Sprintf("[pool] %s: #%d %s -> #%d %s\n", kind, from, fromHost, to, toHost))
+						recordEvent(journalEvent{
+							Type:    journalPoolSwitched,
+							Message: fmt.Sprintf("%s: #%d %s -> #%d %s", kind, from, fromHost, to, toHost),
+							From:    fromHost,
+							To:      toHost,
+						})
+						emitAlert(alertEvent{
+							Kind:    alertPoolSwitched,
+							Message: fmt.Sprintf("Pool %s: %s -> %s", kind, fromHost, toHost),
+							Details: map[string]any{"from": fromHost, "to": toHost, "manual": manual},
+						})
+					}
+				}
+			}
+
+			var (
+				stats    []Stat
+				downFrom time.Time
+			)
+			for _, sn := range snaps {
+				if sn.HasStat {
+					stats = append(stats, sn.Stat)
+				}
+				if !sn.APIDownSince.IsZero() && (downFrom.IsZero() || sn.APIDownSince.Before(downFrom)) {
+					downFrom = sn.APIDownSince
+				}
+			}
+			devMu.Lock()
+			gpuRows := gpuRowsFor(snaps, devices)
+			devMu.Unlock()
+			status := "Running"
+			if !downFrom.IsZero() {
+				status = "Running · API unreachable for " + time.Since(downFrom).Round(time.Second).String()
+			}
+			fyne.Do(func() {
+				if ctx.Err() != nil {
+					return
+				}
+				statusValue.SetText(status)
+				gpus.Update(gpuRows)
+				if conns != nil {
+					pools.Update(conns)
+				}
+				for _, sn := range snaps {
+					if l := instanceLabels[sn.Name]; l != nil {
+						l.SetText(formatInstance(sn))
+					}
+				}
+			})
+			if len(stats) == 0 {
+				continue
+			}
+			s := aggregateStats(stats)
+			if ctx.Err() == nil {
+				setLANStat(s, true)
+				if hist != nil {
+					logHistoryErr(hist.Record(time.Now(), s, shareRuns(snaps)))
+				}
+				for _, ev := range alerts.Check(time.Now(), s, solo) {
+					emitAlert(ev)
+				}
+			}
+			hs := fmt.Sprintf("%.2f MH/s", float64(s.TotalKHs)/1000.0)
+			fyne.Do(func() {
+				if ctx.Err() != nil {
+					return
+				}
+				hashrateValue.Text = hs
+				hashrateValue.Refresh()
+				hashrateHistory.Add(float64(s.TotalKHs) / 1000.0)
+				if !showingHistory {
+					if avg, ok := hashrateHistory.Average(); ok {
+						avgHashrateValue.SetText(fmt.Sprintf("Avg %.2f MH/s", avg))
+					} else {
+						avgHashrateValue.SetText("Avg —")
+					}
+				}
+				sharesValue.SetText(fmt.Sprintf("Accepted %d | Rejected %d | Invalid %d", s.Accepted, s.Rejected, s.Invalid))
+				poolValue.SetText(s.Pool)
+				uptimeValue.SetText(fmt.Sprintf("%d min", s.UptimeMin))
+			})
+		}
+	}
+
+	// launchPlanned starts every planned instance once the pre-start hook is
+	// done. ctx is the start's token: a stop request while the hook was
+	// running, or a newer start, cancels the launch.
+	launchPlanned := func(ctx context.Context, planned []*minerInstance) {
+		procMu.Lock()
+		defer procMu.Unlock()
+		if ctx.Err() != nil {
+			return
+		}
+		startCancel()
+		startCancel = nil
+		multi := len(planned) > 1
+
+		for _, inst := range planned {
+			logLine := appendLog
+			if multi {
+				prefix := "[" + inst.Name + "] "
+				logLine = func(text string) {
+					for _, line := range strings.Split(text, "\n") {
+						if line != "" {
+							line = prefix + line
+						}
+						appendLog(line)
+					}
+				}
+			}
+			if err := launchInstance(inst, logLine); err != nil {
+				for _, started := range instances {
+					started.stopping = true
+					stopInstanceLocked(started)
+				}
+				if len(instances) == 0 {
+					setRunningUI(false)
+				}
+				dialog.ShowError(err, w)
+				return
+			}
+			instances = append(instances, inst)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		statsCancel = cancel
+		go watchStats(ctx)
+
+		setRunningUI(true)
+		if !showingHistory {
+			hashrate10mTitle.SetText(fmt.Sprintf("Hashrate (%s)", hashrateHistory.Window(cfg.PollInterval)))
+		}
+		var names []string
+		for _, inst := range planned {
+			names = append(names, backendDisplayName(inst.Backend))
+		}
+		switch {
+		case cfg.Backend == backendAuto:
+			backendInUseValue.SetText(fmt.Sprintf("Auto → %s", strings.ToUpper(planned[0].Backend)))
+		case multi:
+			backendInUseValue.SetText(strings.Join(names, " + "))
+		default:
+			backendInUseValue.SetText(strings.ToUpper(planned[0].Backend))
+		}
+
+		instanceLabels = map[string]*widget.Label{}
+		instancesGrid.Objects = nil
+		if multi {
+			for i, inst := range planned {
+				l := widget.NewLabel("Waiting for API...")
+				l.Wrapping = fyne.TextWrapWord
+				instanceLabels[inst.Name] = l
+				instancesGrid.Objects = append(instancesGrid.Objects, metricTile(names[i]+" miner", l))
+			}
+			instancesGrid.Layout = layout.NewGridLayoutWithColumns(len(planned))
+			instancesGrid.Show()
+		}
+		instancesGrid.Refresh()
+
+		hooks, hc := cfg.Hooks, runHookContext(cfg, planned)
+		go func() { _ = runHook(context.Background(), hooks, hookPostStart, hc, appendLog) }()
+	}
+
+	// tryStartMiner validates the form and starts the miner in the
+	// background. Failures after it returns (integrity check, pre-start hook)
+	// are reported in dialogs. It must be called on the UI goroutine.
+	var startMiner func()
+	tryStartMiner := func() error {
+		if ethminerErr != nil {
+			return ethminerErr
+		}
+		if err := saveFromUI(); err != nil {
+			return err
+		}
+
+		procMu.Lock()
+		defer procMu.Unlock()
+		if startCancel != nil || len(instances) > 0 {
+			return errMinerRunning
+		}
+
+		if _, err := buildPoolURL(cfg); err != nil {
+			return err
+		}
+		planned, err := planInstances(cfg, ethminerPath)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		startCancel = cancel
+		// Stop stays available so a slow pre-start hook can be cancelled.
+		startBtn.Disable()
+		stopBtn.Enable()
+
+		resetLog()
+		hooks, hc := cfg.Hooks, runHookContext(cfg, planned)
+		binary, trusted := planned[0].Binary, cfg.TrustedEthminers
+		// abort ends this start unless it was cancelled or superseded.
+		abort := func(report func()) {
+			procMu.Lock()
+			defer procMu.Unlock()
+			if ctx.Err() != nil {
+				return
+			}
+			startCancel()
+			startCancel = nil
+			fyne.Do(func() {
+				setRunningUI(false)
+				report()
+			})
+		}
+		go func() {
+			if err := runHook(ctx, hooks, hookPreStart, hc, appendLog); err != nil {
+				abort(func() { dialog.ShowError(fmt.Errorf("pre-start hook failed: %w", err), w) })
+				return
+			}
+			// Re-check the digest on every start, after the hook so that it
+			// can't swap the file between the check and the launch.
+			sum, status, err := verifyEthminer(binary, trusted)
+			if err != nil {
+				abort(func() { handleEthminerErr(err, startMiner) })
+				return
+			}
+			appendLog(fmt.Sprintf("[integrity] %s sha256 %s (%s)\n", binary, sum, status))
+			fyne.Do(func() { launchPlanned(ctx, planned) })
+		}()
+		return nil
+	}
+	startMiner = func() {
+		err := tryStartMiner()
+		switch {
+		case err == nil:
+		case err == ethminerErr:
+			handleEthminerErr(ethminerErr, nil)
+		case errors.Is(err, errMinerRunning):
+			dialog.ShowInformation(appName, "Miner already running", w)
+		default:
+			dialog.ShowError(err, w)
+		}
+	}
+
+	stopMiner := func() {
+		procMu.Lock()
+		defer procMu.Unlock()
+		if startCancel != nil {
+			startCancel()
+			startCancel = nil
+			appendLog("Start cancelled.\n")
+			setRunningUI(false)
+		}
+		if len(instances) == 0 {
+			return
+		}
+		appendLog("\nStopping miner...\n")
+		var victims []*minerInstance
+		for _, inst := range append([]*minerInstance(nil), instances...) {
+			inst.stopping = true
+			if !inst.running() {
+				// Waiting for a scheduled restart; nothing to signal.
+				instances = removeInstance(instances, inst)
+				continue
+			}
+			victims = append(victims, inst)
+		}
+		if len(instances) == 0 {
+			finishRunLocked()
+			return
+		}
+		if strings.TrimSpace(cfg.Hooks.PreStop) == "" {
+			for _, inst := range victims {
+				stopInstanceLocked(inst)
+			}
+			return
+		}
+		planned := append([]*minerInstance(nil), victims...)
+		hooks, hc := cfg.Hooks, runHookContext(cfg, planned)
+		go func() {
+			_ = runHook(context.Background(), hooks, hookPreStop, hc, appendLog)
+			procMu.Lock()
+			defer procMu.Unlock()
+			for _, inst := range victims {
+				if inst.running() {
+					stopInstanceLocked(inst)
+				}
+			}
+		}()
+	}
+
+	startBtn = widget.NewButtonWithIcon("Start mining", theme.MediaPlayIcon(), startMiner)
+	startBtn.Importance = widget.HighImportance
+	stopBtn = widget.NewButtonWithIcon("Stop", theme.MediaStopIcon(), stopMiner)
+	stopBtn.Importance = widget.DangerImportance
 
 	if ethminerErr != nil {
 		startBtn.Disable()
@@ -759,6 +1751,855 @@ func main() {
 		stopBtn.Disable()
 	}
 
+	isRunning := func() bool {
+		procMu.Lock()
+		defer procMu.Unlock()
+		return startCancel != nil || len(instances) > 0
+	}
+
+	// controlAll runs a control call against every running instance in the
+	// background and reports failures per instance.
+	controlAll := func(action string, fn func(inst instanceSnapshot, api *apiClient) error) {
+		procMu.Lock()
+		var (
+			snaps []instanceSnapshot
+			apis  []*apiClient
+		)
+		for _, inst := range instances {
+			if inst.running() && inst.api != nil {
+				snaps = append(snaps, inst.snapshot())
+				apis = append(apis, inst.api)
+			}
+		}
+		procMu.Unlock()
+		if len(apis) == 0 {
+			dialog.ShowInformation("Miner control", "The miner is not running.", w)
+			return
+		}
+		appendLog(fmt.Sprintf("[control] %s\n", action))
+		go func() {
+			var errs []string
+			for i, api := range apis {
+				if err := fn(snaps[i], api); err != nil {
+					errs = append(errs, fmt.Sprintf("%s: %v", backendDisplayName(snaps[i].Backend), err))
+				}
+			}
+			if len(errs) == 0 {
+				return
+			}
+			msg := strings.Join(errs, "\n")
+			appendLog(fmt.Sprintf("[control] %s failed: %s\n", action, msg))
+			fyne.Do(func() { dialog.ShowError(fmt.Errorf("%s failed:\n%s", action, msg), w) })
+		}()
+	}
+
+	restartMinerBtn.OnTapped = func() {
+		controlAll("restart mining", func(_ instanceSnapshot, api *apiClient) error { return api.Restart() })
+	}
+	rebootBtn.OnTapped = func() {
+		dialog.ShowConfirm("Reboot rig",
+			"ethminer will run the reboot script next to its binary (reboot.sh / reboot.bat). Continue?",
+			func(ok bool) {
+				if ok {
+					controlAll("reboot", func(_ instanceSnapshot, api *apiClient) error { return api.Reboot() })
+				}
+			}, w)
+	}
+	pauseAllBtn.OnTapped = func() {
+		pause := !gpusPaused
+		action := "resume all GPUs"
+		if pause {
+			action = "pause all GPUs"
+		}
+		controlAll(action, func(sn instanceSnapshot, api *apiClient) error {
+			// miner_pausegpu takes the miner's own device index.
+			n := len(sn.Devices)
+			if sn.HasStat && len(sn.Stat.Devices) > 0 {
+				n = len(sn.Stat.Devices)
+			}
+			for i := 0; i < n; i++ {
+				if err := api.PauseGPU(i, pause); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		gpusPaused = pause
+		gpus.SetPaused(pause)
+		if pause {
+			pauseAllBtn.SetText("Resume GPUs")
+			pauseAllBtn.SetIcon(theme.MediaPlayIcon())
+		} else {
+			pauseAllBtn.SetText("Pause GPUs")
+			pauseAllBtn.SetIcon(theme.MediaPauseIcon())
+		}
+	}
+	pauseGPU = func(key gpuKey, pause bool) {
+		procMu.Lock()
+		var api *apiClient
+		for _, inst := range instances {
+			if inst.Name == key.Instance && inst.running() {
+				api = inst.api
+			}
+		}
+		procMu.Unlock()
+		if api == nil {
+			gpus.Failed(key)
+			return
+		}
+		verb := "resume"
+		if pause {
+			verb = "pause"
+		}
+		appendLog(fmt.Sprintf("[control] %s %s GPU %d\n", verb, backendDisplayName(key.Instance), key.Index))
+		go func() {
+			if err := api.PauseGPU(key.Index, pause); err != nil {
+				appendLog(fmt.Sprintf("[control] %s GPU %d failed: %v\n", verb, key.Index, err))
+				fyne.Do(func() {
+					gpus.Failed(key)
+					dialog.ShowError(err, w)
+				})
+			}
+		}()
+	}
+	verbositySelect.OnChanged = func(v string) {
+		level, err := strconv.Atoi(v)
+		if err != nil {
+			return
+		}
+		controlAll("set verbosity "+v, func(_ instanceSnapshot, api *apiClient) error { return api.SetVerbosity(level) })
+	}
+	switchPool = func(c poolConnection) {
+		poolTrack.Requested(c.Index)
+		controlAll(fmt.Sprintf("switch to pool #%d %s", c.Index, poolHost(c.URI)), func(_ instanceSnapshot, api *apiClient) error {
+			return api.SetActiveConnection(c.Index)
+		})
+	}
+
+	sched := newMiningScheduler(time.Now)
+	sched.SetSchedule(cfg.Schedule)
+
+	scheduleValue := widget.NewLabel("—")
+	scheduleValue.Wrapping = fyne.TextWrapWord
+	scheduleSummary := widget.NewLabel("")
+	overrideBtn := widget.NewButtonWithIcon("Override", theme.MediaSkipNextIcon(), nil)
+	overrideBtn.Importance = widget.LowImportance
+	scheduleRow := container.NewBorder(nil, nil, widget.NewIcon(theme.HistoryIcon()), overrideBtn, scheduleValue)
+
+	refreshScheduleUI := func() {
+		if cfg.Schedule.Enabled {
+			scheduleSummary.SetText(fmt.Sprintf("On (%d windows)", len(cfg.Schedule.Windows)))
+		} else {
+			scheduleSummary.SetText("Off")
+		}
+		st := sched.Status()
+		if !st.Enabled {
+			scheduleRow.Hide()
+			return
+		}
+		scheduleRow.Show()
+		state := "idle window"
+		if st.Active {
+			state = "mining window"
+		}
+		var text string
+		switch {
+		case !st.OverrideUntil.IsZero():
+			text = fmt.Sprintf("Schedule: manual override until %s", st.OverrideUntil.Format("Mon 15:04"))
+		case !st.HasNext:
+			text = fmt.Sprintf("Schedule: %s, no upcoming change", state)
+		case st.NextActive:
+			text = fmt.Sprintf("Schedule: %s, next start %s", state, st.Next.Format("Mon 15:04"))
+		default:
+			text = fmt.Sprintf("Schedule: %s, next stop %s", state, st.Next.Format("Mon 15:04"))
+		}
+		scheduleValue.SetText(text)
+		if st.HasNext && st.OverrideUntil.IsZero() {
+			overrideBtn.Enable()
+		} else {
+			overrideBtn.Disable()
+		}
+	}
+
+	applyScheduleAction := func(act scheduleAction, reason string) {
+		switch act {
+		case scheduleStart:
+			if !isRunning() {
+				startMiner()
+				appendLog(fmt.Sprintf("[schedule] %s: starting miner\n", reason))
+			}
+		case scheduleStop:
+			if isRunning() {
+				appendLog(fmt.Sprintf("[schedule] %s: stopping miner\n", reason))
+				stopMiner()
+			}
+		}
+	}
+
+	overrideBtn.OnTapped = func() {
+		act, until := sched.Override()
+		applyScheduleAction(act, fmt.Sprintf("manual override until %s", until.Format("Mon 15:04")))
+		refreshScheduleUI()
+	}
+
+	scheduleDays := []time.Weekday{
+		time.Monday, time.Tuesday, time.Wednesday, time.Thursday,
+		time.Friday, time.Saturday, time.Sunday,
+	}
+	editScheduleBtn := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		enabledCheck := widget.NewCheck("Mine only inside these windows", nil)
+		enabledCheck.SetChecked(cfg.Schedule.Enabled)
+		dayEntries := make([]*widget.Entry, len(scheduleDays))
+		grid := container.NewGridWithColumns(2)
+		for i, day := range scheduleDays {
+			e := widget.NewEntry()
+			e.SetText(cfg.Schedule.FormatDay(day))
+			e.SetPlaceHolder("e.g. 00:00-07:00, 22:00-24:00")
+			dayEntries[i] = e
+			grid.Add(fieldLabel(day.String()))
+			grid.Add(e)
+		}
+		hint := widget.NewLabel("Windows are HH:MM-HH:MM, comma separated. A window ending before it starts runs past midnight.")
+		hint.Wrapping = fyne.TextWrapWord
+		hint.TextStyle = fyne.TextStyle{Italic: true}
+		body := container.NewVBox(enabledCheck, grid, hint)
+
+		d := dialog.NewCustomConfirm("Mining schedule", "Save", "Cancel", body, func(ok bool) {
+			if !ok {
+				return
+			}
+			next := ScheduleConfig{Enabled: enabledCheck.Checked}
+			for i, day := range scheduleDays {
+				windows, err := parseDayWindows(day, dayEntries[i].Text)
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				next.Windows = append(next.Windows, windows...)
+			}
+			if err := next.Validate(); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			cfg.Schedule = next
+			if err := persistConfig(); err != nil {
+				dialog.ShowError(err, w)
+			}
+			sched.SetSchedule(next)
+			refreshScheduleUI()
+		}, w)
+		d.Resize(fyne.NewSize(520, 0))
+		d.Show()
+	})
+	refreshScheduleUI()
+
+	hooksSummary := widget.NewLabel("")
+	refreshHooksUI := func() {
+		if n := cfg.Hooks.count(); n > 0 {
+			hooksSummary.SetText(fmt.Sprintf("%d configured", n))
+		} else {
+			hooksSummary.SetText("None")
+		}
+	}
+	editHooksBtn := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		newHookEntry := func(text string) *widget.Entry {
+			e := widget.NewEntry()
+			e.SetText(text)
+			e.SetPlaceHolder("shell command (optional)")
+			return e
+		}
+		preStart := newHookEntry(cfg.Hooks.PreStart)
+		postStart := newHookEntry(cfg.Hooks.PostStart)
+		preStop := newHookEntry(cfg.Hooks.PreStop)
+		postExit := newHookEntry(cfg.Hooks.PostExit)
+		onCrash := newHookEntry(cfg.Hooks.OnCrash)
+		timeoutEntry := widget.NewEntry()
+		if cfg.Hooks.TimeoutSec > 0 {
+			timeoutEntry.SetText(strconv.Itoa(cfg.Hooks.TimeoutSec))
+		}
+		timeoutEntry.SetPlaceHolder(strconv.Itoa(defaultHookTimeout))
+
+		grid := container.NewGridWithColumns(2,
+			fieldLabel("Pre-start"), preStart,
+			fieldLabel("Post-start"), postStart,
+			fieldLabel("Pre-stop"), preStop,
+			fieldLabel("Post-exit"), postExit,
+			fieldLabel("On crash"), onCrash,
+			fieldLabel("Timeout (s)"), timeoutEntry,
+		)
+		hint := widget.NewLabel("A failing pre-start hook aborts the start. Hooks get OLIVETUM_HOOK, OLIVETUM_MODE, OLIVETUM_BACKEND, OLIVETUM_INSTANCE, OLIVETUM_DEVICES, OLIVETUM_POOL_URL and, after exit, OLIVETUM_EXIT_CODE.")
+		hint.Wrapping = fyne.TextWrapWord
+		hint.TextStyle = fyne.TextStyle{Italic: true}
+
+		d := dialog.NewCustomConfirm("Hook commands", "Save", "Cancel", container.NewVBox(grid, hint), func(ok bool) {
+			if !ok {
+				return
+			}
+			next := HooksConfig{
+				PreStart:  strings.TrimSpace(preStart.Text),
+				PostStart: strings.TrimSpace(postStart.Text),
+				PreStop:   strings.TrimSpace(preStop.Text),
+				PostExit:  strings.TrimSpace(postExit.Text),
+				OnCrash:   strings.TrimSpace(onCrash.Text),
+			}
+			if text := strings.TrimSpace(timeoutEntry.Text); text != "" {
+				v, err := strconv.Atoi(text)
+				if err != nil || v < 1 || v > 600 {
+					dialog.ShowError(errors.New("invalid hook timeout (1..600)"), w)
+					return
+				}
+				next.TimeoutSec = v
+			}
+			cfg.Hooks = next
+			if err := persistConfig(); err != nil {
+				dialog.ShowError(err, w)
+			}
+			refreshHooksUI()
+		}, w)
+		d.Resize(fyne.NewSize(560, 0))
+		d.Show()
+	})
+	refreshHooksUI()
+
+	alertsSummary := widget.NewLabel("")
+	refreshAlertsUI := func() {
+		var parts []string
+		if cfg.Alerts.MinMHs > 0 {
+			parts = append(parts, fmt.Sprintf("< %.2f MH/s", cfg.Alerts.MinMHs))
+		}
+		if cfg.Alerts.MaxRejectPct > 0 {
+			parts = append(parts, fmt.Sprintf("rejects ≥ %.0f%%", cfg.Alerts.MaxRejectPct))
+		}
+		if cfg.Alerts.MaxTemp > 0 {
+			parts = append(parts, fmt.Sprintf("≥ %d°C", cfg.Alerts.MaxTemp))
+		}
+		text := fmt.Sprintf("%d webhook(s)", len(cfg.Webhooks))
+		if len(parts) > 0 {
+			text += " · " + strings.Join(parts, ", ")
+		}
+		alertsSummary.SetText(text)
+	}
+	editAlertsBtn := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		newThresholdEntry := func(v float64, placeholder string) *widget.Entry {
+			e := widget.NewEntry()
+			if v > 0 {
+				e.SetText(strconv.FormatFloat(v, 'f', -1, 64))
+			}
+			e.SetPlaceHolder(placeholder)
+			return e
+		}
+		minMHsEntry := newThresholdEntry(cfg.Alerts.MinMHs, "off")
+		rejectEntry := newThresholdEntry(cfg.Alerts.MaxRejectPct, "off")
+		tempEntry := newThresholdEntry(float64(cfg.Alerts.MaxTemp), "off")
+
+		type hookRow struct {
+			url    *widget.Entry
+			format *widget.Select
+		}
+		var rows []*hookRow
+		rowsBox := container.NewVBox()
+		formatNames := make([]string, len(webhookFormats))
+		for i, f := range webhookFormats {
+			formatNames[i] = webhookFormatName(f)
+		}
+		formatOf := func(name string) string {
+			for _, f := range webhookFormats {
+				if webhookFormatName(f) == name {
+					return f
+				}
+			}
+			return webhookJSON
+		}
+		hookOf := func(r *hookRow) WebhookConfig {
+			return WebhookConfig{URL: strings.TrimSpace(r.url.Text), Format: formatOf(r.format.Selected)}
+		}
+		var renderRows func()
+		addRow := func(h WebhookConfig) {
+			r := &hookRow{url: widget.NewEntry(), format: widget.NewSelect(formatNames, nil)}
+			r.url.SetText(h.URL)
+			r.url.SetPlaceHolder("https://…")
+			r.format.SetSelected(webhookFormatName(h.Format))
+			rows = append(rows, r)
+			renderRows()
+		}
+		renderRows = func() {
+			var objs []fyne.CanvasObject
+			for _, r := range rows {
+				var testBtn *widget.Button
+				testBtn = widget.NewButton("Send test", func() {
+					h := hookOf(r)
+					testBtn.Disable()
+					go func() {
+						ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+						defer cancel()
+						err := webhooks.Test(ctx, h)
+						fyne.Do(func() {
+							testBtn.Enable()
+							if err != nil {
+								dialog.ShowError(fmt.Errorf("test alert to %s: %w", redactURL(h.URL), err), w)
+								return
+							}
+							dialog.ShowInformation("Webhook", "Test alert delivered to "+redactURL(h.URL)+".", w)
+						})
+					}()
+				})
+				removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+					rows = slices.DeleteFunc(rows, func(x *hookRow) bool { return x == r })
+					renderRows()
+				})
+				objs = append(objs, container.NewBorder(nil, nil, nil, container.NewHBox(r.format, testBtn, removeBtn), r.url))
+			}
+			rowsBox.Objects = objs
+			rowsBox.Refresh()
+		}
+		for _, h := range cfg.Webhooks {
+			addRow(h)
+		}
+		addHookBtn := widget.NewButtonWithIcon("Add webhook", theme.ContentAddIcon(), func() {
+			addRow(WebhookConfig{Format: webhookJSON})
+		})
+
+		grid := container.NewGridWithColumns(2,
+			fieldLabel("Hashrate below (MH/s)"), minMHsEntry,
+			fieldLabel("Rejected shares (%)"), rejectEntry,
+			fieldLabel("GPU temperature (°C)"), tempEntry,
+		)
+		notify := cfg.Notifications
+		newNotifyCheck := func(label string, on *bool) *widget.Check {
+			c := widget.NewCheck(label, func(v bool) { *on = v })
+			c.SetChecked(*on)
+			return c
+		}
+		notifyChecks := container.NewGridWithColumns(3,
+			newNotifyCheck("Miner crashed", &notify.Crash),
+			newNotifyCheck("Miner restarted", &notify.Restart),
+			newNotifyCheck("Rejected shares", &notify.Rejects),
+			newNotifyCheck("GPU temperature", &notify.Temperature),
+			newNotifyCheck("Block found (solo)", &notify.BlockFound),
+		)
+		hint := widget.NewLabel("Alerts are sent when a miner crashes or is restarted, the pool switches, a block is found in solo mode, and when a threshold above is crossed. Each alert type is posted at most once a minute per webhook. Leave a threshold empty to disable it.")
+		hint.Wrapping = fyne.TextWrapWord
+		hint.TextStyle = fyne.TextStyle{Italic: true}
+		content := container.NewVBox(grid, widget.NewSeparator(), fieldLabel("Desktop notifications"), notifyChecks,
+			widget.NewSeparator(), fieldLabel("Webhooks"), rowsBox, container.NewHBox(addHookBtn), hint)
+
+		d := dialog.NewCustomConfirm("Alerts", "Save", "Cancel", content, func(ok bool) {
+			if !ok {
+				return
+			}
+			parse := func(e *widget.Entry, name string) (float64, error) {
+				text := strings.TrimSpace(e.Text)
+				if text == "" {
+					return 0, nil
+				}
+				v, err := strconv.ParseFloat(text, 64)
+				if err != nil {
+					return 0, fmt.Errorf("invalid %s", name)
+				}
+				return v, nil
+			}
+			var next AlertsConfig
+			var err error
+			if next.MinMHs, err = parse(minMHsEntry, "hashrate threshold"); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if next.MaxRejectPct, err = parse(rejectEntry, "rejected share threshold"); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			temp, err := parse(tempEntry, "temperature limit")
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			next.MaxTemp = int(temp)
+			if err := next.Validate(); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			var hooks []WebhookConfig
+			for _, r := range rows {
+				h := hookOf(r)
+				if h.URL == "" {
+					continue
+				}
+				if err := h.Validate(); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				hooks = append(hooks, h)
+			}
+			cfg.Alerts = next
+			cfg.Webhooks = hooks
+			cfg.Notifications = notify
+			if err := persistConfig(); err != nil {
+				dialog.ShowError(err, w)
+			}
+			alerts.SetConfig(next)
+			webhooks.Configure(hooks)
+			refreshAlertsUI()
+		}, w)
+		d.Resize(fyne.NewSize(680, 0))
+		d.Show()
+	})
+	refreshAlertsUI()
+
+	// applyPush restarts the push exporters from cfg.Push; it is set once the
+	// stats it pushes can be collected.
+	var applyPush func()
+	pushSummary := widget.NewLabel("")
+	refreshPushUI := func() {
+		var on []string
+		if cfg.Push.Influx.Enabled {
+			on = append(on, "InfluxDB")
+		}
+		if cfg.Push.StatsD.Enabled {
+			on = append(on, "StatsD")
+		}
+		if len(on) == 0 {
+			pushSummary.SetText("Off")
+			return
+		}
+		pushSummary.SetText(fmt.Sprintf("%s every %s", strings.Join(on, " + "), cfg.Push.interval()))
+	}
+	editPushBtn := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		p := cfg.Push
+		intervalEntry := widget.NewEntry()
+		if p.IntervalSec > 0 {
+			intervalEntry.SetText(strconv.Itoa(p.IntervalSec))
+		}
+		intervalEntry.SetPlaceHolder(strconv.Itoa(defaultPushInterval))
+
+		influxCheck := widget.NewCheck("Send to InfluxDB (line protocol over HTTP)", nil)
+		influxCheck.SetChecked(p.Influx.Enabled)
+		influxURLEntry := widget.NewEntry()
+		influxURLEntry.SetText(p.Influx.URL)
+		influxURLEntry.SetPlaceHolder("http://influx:8086/api/v2/write?org=home&bucket=mining")
+		influxTokenEntry := widget.NewPasswordEntry()
+		influxTokenEntry.SetText(p.Influx.Token)
+		influxTokenEntry.SetPlaceHolder("API token (optional)")
+
+		statsdCheck := widget.NewCheck("Send to StatsD (UDP)", nil)
+		statsdCheck.SetChecked(p.StatsD.Enabled)
+		statsdAddrEntry := widget.NewEntry()
+		statsdAddrEntry.SetText(p.StatsD.Addr)
+		statsdAddrEntry.SetPlaceHolder("statsd:8125")
+		statsdPrefixEntry := widget.NewEntry()
+		statsdPrefixEntry.SetText(p.StatsD.Prefix)
+		statsdPrefixEntry.SetPlaceHolder(defaultStatsDPrefix)
+		statsdTagsCheck := widget.NewCheck("Send tags (DogStatsD / Telegraf)", nil)
+		statsdTagsCheck.SetChecked(p.StatsD.Tags)
+
+		grid := container.NewGridWithColumns(2,
+			fieldLabel("Interval (s)"), intervalEntry,
+			widget.NewLabel(""), influxCheck,
+			fieldLabel("Write URL"), influxURLEntry,
+			fieldLabel("Token"), influxTokenEntry,
+			widget.NewLabel(""), statsdCheck,
+			fieldLabel("Address"), statsdAddrEntry,
+			fieldLabel("Metric prefix"), statsdPrefixEntry,
+			widget.NewLabel(""), statsdTagsCheck,
+		)
+		hint := widget.NewLabel("Rig, worker, instance and GPU are sent as tags. While InfluxDB is unreachable up to 2880 samples are buffered and sent in order later; StatsD has no timestamps, so only the newest sample is retried.")
+		hint.Wrapping = fyne.TextWrapWord
+		hint.TextStyle = fyne.TextStyle{Italic: true}
+
+		d := dialog.NewCustomConfirm("Push exporters", "Save", "Cancel", container.NewVBox(grid, hint), func(ok bool) {
+			if !ok {
+				return
+			}
+			next := PushConfig{
+				Influx: InfluxConfig{
+					Enabled: influxCheck.Checked,
+					URL:     strings.TrimSpace(influxURLEntry.Text),
+					Token:   strings.TrimSpace(influxTokenEntry.Text),
+				},
+				StatsD: StatsDConfig{
+					Enabled: statsdCheck.Checked,
+					Addr:    strings.TrimSpace(statsdAddrEntry.Text),
+					Prefix:  strings.Trim(strings.TrimSpace(statsdPrefixEntry.Text), "."),
+					Tags:    statsdTagsCheck.Checked,
+				},
+			}
+			if text := strings.TrimSpace(intervalEntry.Text); text != "" {
+				v, err := strconv.Atoi(text)
+				if err != nil || v < 1 {
+					dialog.ShowError(errors.New("invalid push interval (1..3600 s)"), w)
+					return
+				}
+				next.IntervalSec = v
+			}
+			if err := next.Validate(); err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			cfg.Push = next
+			if err := persistConfig(); err != nil {
+				dialog.ShowError(err, w)
+			}
+			applyPush()
+			refreshPushUI()
+		}, w)
+		d.Resize(fyne.NewSize(620, 0))
+		d.Show()
+	})
+	refreshPushUI()
+
+	// applyMQTT restarts the MQTT bridge from cfg.MQTT; it is set once the
+	// miner controls it routes commands to exist.
+	var applyMQTT func()
+	mqttSummary := widget.NewLabel("")
+	refreshMQTTUI := func() {
+		if !cfg.MQTT.Enabled {
+			mqttSummary.SetText("Off")
+			return
+		}
+		text := cfg.MQTT.Broker + " · " + cfg.MQTT.topicPrefix() + "/" + mqttNodeID(rigName())
+		if cfg.MQTT.Discovery {
+			text += " · Home Assistant"
+		}
+		mqttSummary.SetText(text)
+	}
+	editMQTTBtn := widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() {
+		m := cfg.MQTT
+		enableCheck := widget.NewCheck("Publish telemetry and accept commands over MQTT", nil)
+		enableCheck.SetChecked(m.Enabled)
+		brokerEntry := widget.NewEntry()
+		brokerEntry.SetText(m.Broker)
+		brokerEntry.SetPlaceHolder("127.0.0.1:1883")
+		tlsCheck := widget.NewCheck("Use TLS", nil)
+		tlsCheck.SetChecked(m.TLS)
+		userEntry := widget.NewEntry()
+		userEntry.SetText(m.Username)
+		userEntry.SetPlaceHolder("optional")
+		passEntry := widget.NewPasswordEntry()
+		passEntry.SetText(m.Password)
+		passEntry.SetPlaceHolder("optional")
+		prefixEntry := widget.NewEntry()
+		prefixEntry.SetText(m.TopicPrefix)
+		prefixEntry.SetPlaceHolder(defaultMQTTTopicPrefix)
+		discoveryCheck := widget.NewCheck("Home Assistant discovery", nil)
+		discoveryCheck.SetChecked(m.Discovery)
+		discoveryEntry := widget.NewEntry()
+		discoveryEntry.SetText(m.DiscoveryPrefix)
+		discoveryEntry.SetPlaceHolder(defaultMQTTDiscoveryPrefix)
+
+		grid := container.NewGridWithColumns(2,
+			widget.NewLabel(""), enableCheck,
+			fieldLabel("Broker"), container.NewBorder(nil, nil, nil, tlsCheck, brokerEntry),
+			fieldLabel("User name"), userEntry,
+			fieldLabel("Password"), passEntry,
+			fieldLabel("Topic prefix"), prefixEntry,
+			widget.NewLabel(""), discoveryCheck,
+			fieldLabel("Discovery prefix"), discoveryEntry,
+		)
+		hint := widget.NewLabel(fmt.Sprintf("Topics live under <prefix>/%s (the rig name). Publish to …/command/start or …/command/stop, or ON/OFF to …/gpu/cuda-0/pause/set, to control the miner.", mqttNodeID(rigName())))
+		hint.Wrapping = fyne.TextWrapWord
+		hint.TextStyle = fyne.TextStyle{Italic: true}
+
+		d := dialog.NewCustomConfirm("MQTT", "Save", "Cancel", container.NewVBox(grid, hint), func(ok bool) {
+			if !ok {
+				return
+			}
+			next := MQTTConfig{
+				Enabled:         enableCheck.Checked,
+				Broker:          strings.TrimSpace(brokerEntry.Text),
+				TLS:             tlsCheck.Checked,
+				Username:        strings.TrimSpace(userEntry.Text),
+				Password:        passEntry.Text,
+				TopicPrefix:     strings.Trim(strings.TrimSpace(prefixEntry.Text), "/"),
+				Discovery:       discoveryCheck.Checked,
+				DiscoveryPrefix: strings.Trim(strings.TrimSpace(discoveryEntry.Text), "/"),
+			}
+			if next.Enabled {
+				if err := next.Validate(); err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+			}
+			cfg.MQTT = next
+			if err := persistConfig(); err != nil {
+				dialog.ShowError(err, w)
+			}
+			applyMQTT()
+			refreshMQTTUI()
+		}, w)
+		d.Resize(fyne.NewSize(600, 0))
+		d.Show()
+	})
+	refreshMQTTUI()
+
+	loginCheck := widget.NewCheck("Launch on login", nil)
+	loginCheck.SetChecked(autostartInstalled())
+	if !autostartSupported() {
+		loginCheck.Disable()
+	}
+	loginCheck.OnChanged = func(on bool) {
+		if on == autostartInstalled() {
+			return
+		}
+		if err := setAutostart(on); err != nil {
+			dialog.ShowError(err, w)
+			loginCheck.SetChecked(!on)
+		}
+	}
+
+	refreshMinerVersionUI := func() {
+		if ethminerErr != nil {
+			minerVersionValue.SetText("Not available: " + ethminerErr.Error())
+			return
+		}
+		minerVersionValue.SetText(fmt.Sprintf("%s · %s · %s", activeMiner.String(), activeMiner.Integrity, activeMiner.Path))
+	}
+	refreshMinerVersionUI()
+
+	const autoDetectBuildLabel = "Auto-detect (next to app or PATH)"
+	buildSelect := widget.NewSelect(nil, nil)
+	syncingBuilds := false
+	refreshBuildsUI := func() {
+		opts := []string{autoDetectBuildLabel}
+		selected := autoDetectBuildLabel
+		for _, b := range cfg.EthminerBuilds {
+			opts = append(opts, buildLabel(b))
+			if b.Path == cfg.EthminerPath {
+				selected = buildLabel(b)
+			}
+		}
+		syncingBuilds = true
+		buildSelect.Options = opts
+		buildSelect.SetSelected(selected)
+		syncingBuilds = false
+	}
+
+	// useEthminer switches the active binary. An empty path means auto-detect.
+	// The change applies to the next start; a running miner keeps its binary.
+	var useEthminer func(path string)
+	useEthminer = func(path string) {
+		cfg.EthminerPath = path
+		buildSelect.Disable()
+		minerVersionValue.SetText("Checking ethminer...")
+		trusted := append([]TrustedBinary(nil), cfg.TrustedEthminers...)
+		go func() {
+			info, err := resolveEthminer(cfg, trusted)
+			fyne.Do(func() {
+				buildSelect.Enable()
+				activeMiner, ethminerErr = info, err
+				ethminerPath = info.Path
+				if err == nil {
+					cfg.EthminerBuilds = registerBuild(cfg.EthminerBuilds, info)
+				}
+				if saveErr := persistConfig(); saveErr != nil {
+					appendLog(fmt.Sprintf("[config] %v\n", saveErr))
+				}
+				refreshBuildsUI()
+				refreshMinerVersionUI()
+				if !isRunning() {
+					if err != nil {
+						startBtn.Disable()
+					} else {
+						startBtn.Enable()
+					}
+				}
+				if err != nil {
+					handleEthminerErr(err, func() { useEthminer(path) })
+					return
+				}
+				refreshDevices()
+			})
+		}()
+	}
+	buildSelect.OnChanged = func(label string) {
+		if syncingBuilds {
+			return
+		}
+		if label == autoDetectBuildLabel {
+			useEthminer("")
+			return
+		}
+		for _, b := range cfg.EthminerBuilds {
+			if buildLabel(b) == label {
+				useEthminer(b.Path)
+				return
+			}
+		}
+	}
+	var addBuild func(path string)
+	addBuild = func(path string) {
+		trusted := append([]TrustedBinary(nil), cfg.TrustedEthminers...)
+		go func() {
+			sum, status, err := verifyEthminer(path, trusted)
+			var info ethminerInfo
+			if err == nil {
+				info, err = probeEthminer(path)
+				info.SHA256, info.Integrity = sum, status
+			}
+			if err == nil {
+				err = info.compatible()
+			}
+			fyne.Do(func() {
+				if err != nil {
+					handleEthminerErr(err, func() { addBuild(path) })
+					return
+				}
+				cfg.EthminerBuilds = registerBuild(cfg.EthminerBuilds, info)
+				useEthminer(info.Path)
+			})
+		}()
+	}
+	addBuildBtn := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() {
+		dialog.ShowFileOpen(func(r fyne.URIReadCloser, err error) {
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if r == nil {
+				return
+			}
+			path := r.URI().Path()
+			_ = r.Close()
+			addBuild(path)
+		}, w)
+	})
+	removeBuildBtn := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		if cfg.EthminerPath == "" {
+			return
+		}
+		cfg.EthminerBuilds = removeBuild(cfg.EthminerBuilds, cfg.EthminerPath)
+		useEthminer("")
+	})
+	refreshBuildsUI()
+
+	handleEthminerErr = func(err error, retry func()) {
+		var untrusted *untrustedBinaryError
+		if !errors.As(err, &untrusted) {
+			dialog.ShowError(err, w)
+			return
+		}
+		hashEntry := widget.NewEntry()
+		hashEntry.SetText(untrusted.SHA256)
+		msg := widget.NewLabel(fmt.Sprintf("%s does not match any known-good ethminer build. This binary receives your wallet address; only trust it if you built it yourself or got it from a source you trust.", untrusted.Path))
+		msg.Wrapping = fyne.TextWrapWord
+		body := container.NewVBox(msg, fieldLabel("SHA-256"), hashEntry)
+		d := dialog.NewCustomConfirm("Unverified ethminer binary", "Trust binary", "Cancel", body, func(ok bool) {
+			if !ok {
+				return
+			}
+			cfg.TrustedEthminers = trustBinary(cfg.TrustedEthminers, untrusted.Path, untrusted.SHA256)
+			if err := persistConfig(); err != nil {
+				dialog.ShowError(err, w)
+			}
+			appendLog(fmt.Sprintf("[integrity] trusted %s (sha256 %s)\n", untrusted.Path, untrusted.SHA256))
+			if retry != nil {
+				retry()
+			} else {
+				useEthminer(cfg.EthminerPath)
+			}
+		}, w)
+		d.Resize(fyne.NewSize(560, 0))
+		d.Show()
+	}
+
 	var advancedOpen bool
 	advancedToggleBtn := widget.NewButtonWithIcon("Advanced options", theme.SettingsIcon(), nil)
 	advancedToggleBtn.Importance = widget.LowImportance
@@ -778,9 +2619,29 @@ func main() {
 	devicesScroll.SetMinSize(fyne.NewSize(0, 240))
 
 	advancedGrid := container.NewGridWithColumns(2,
+		fieldLabel("ethminer"), container.NewBorder(nil, nil, nil, container.NewHBox(addBuildBtn, removeBuildBtn), buildSelect),
 		fieldLabel("GPU backend"), backendSelect,
+		fieldLabel("Failover pools"), failoverEntry,
 		fieldLabel("Display interval (s)"), displayIntervalEntry,
+		fieldLabel("Stats poll interval (s)"), pollIntervalEntry,
+		widget.NewLabel(""), lanShareCheck,
+		fieldLabel("Rig name"), rigNameEntry,
+		fieldLabel("LAN stats port"), lanPortEntry,
+		widget.NewLabel(""), metricsCheck,
+		fieldLabel("Metrics address"), container.NewGridWithColumns(2, metricsBindEntry, metricsPortEntry),
+		widget.NewLabel(""), restCheck,
+		fieldLabel("REST API port"), restPortEntry,
+		fieldLabel("REST API token"), container.NewBorder(nil, nil, nil, container.NewHBox(copyTokenBtn, newTokenBtn), restTokenLabel),
+		fieldLabel("Restart on exit"), restartSelect,
 		widget.NewLabel(""), reportHashrateCheck,
+		fieldLabel("Schedule"), container.NewBorder(nil, nil, nil, editScheduleBtn, scheduleSummary),
+		fieldLabel("Hooks"), container.NewBorder(nil, nil, nil, editHooksBtn, hooksSummary),
+		fieldLabel("Alerts"), container.NewBorder(nil, nil, nil, editAlertsBtn, alertsSummary),
+		fieldLabel("Push exporters"), container.NewBorder(nil, nil, nil, editPushBtn, pushSummary),
+		fieldLabel("MQTT"), container.NewBorder(nil, nil, nil, editMQTTBtn, mqttSummary),
+		fieldLabel("Startup delay (s)"), startupDelayEntry,
+		widget.NewLabel(""), startOnLaunchCheck,
+		widget.NewLabel(""), loginCheck,
 	)
 	advancedBody := container.NewVBox(
 		advancedGrid,
@@ -804,9 +2665,150 @@ func main() {
 		}
 	}
 
-	hashrate10mTitle := widget.NewLabelWithStyle("Hashrate (10 min)", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
-	hashrate10mTitle.Wrapping = fyne.TextWrapOff
-	hashrate10mHeader := container.NewHBox(widget.NewIcon(theme.HistoryIcon()), hashrate10mTitle, layout.NewSpacer(), avgHashrateValue)
+	// showHistoryRange fills the history chart for the selected range; the
+	// "Live" entry switches back to the polling chart.
+	showHistoryRange := func() {
+		idx := slices.Index(rangeLabels, historyRangeSelect.Selected)
+		if idx <= 0 || hist == nil {
+			showingHistory = false
+			historyChart.Object().Hide()
+			hashrateHistory.Object().Show()
+			hashrate10mTitle.SetText(fmt.Sprintf("Hashrate (%s)", hashrateHistory.Window(cfg.PollInterval)))
+			if avg, ok := hashrateHistory.Average(); ok {
+				avgHashrateValue.SetText(fmt.Sprintf("Avg %.2f MH/s", avg))
+			} else {
+				avgHashrateValue.SetText("Avg —")
+			}
+			return
+		}
+		r := historyRanges[idx]
+		now := time.Now()
+		var (
+			mhs   []float64
+			sum   float64
+			count int
+		)
+		for _, p := range hist.Points(r.res, now.Add(-r.span), now.Add(time.Minute)) {
+			mhs = append(mhs, p.KHs/1000)
+			sum += p.KHs * float64(p.N)
+			count += p.N
+		}
+		showingHistory = true
+		historyChart.SetPoints(mhs)
+		hashrateHistory.Object().Hide()
+		historyChart.Object().Show()
+		hashrate10mTitle.SetText(fmt.Sprintf("Hashrate (%s)", r.label))
+		if count > 0 {
+			avgHashrateValue.SetText(fmt.Sprintf("Avg %.2f MH/s", sum/float64(count)/1000))
+		} else {
+			avgHashrateValue.SetText("Avg —")
+		}
+	}
+	historyRangeSelect.OnChanged = func(string) { showHistoryRange() }
+	go func() {
+		for range time.Tick(time.Minute) {
+			fyne.Do(func() {
+				if showingHistory {
+					showHistoryRange()
+				}
+			})
+		}
+	}()
+
+	const exportTimeLayout = "2006-01-02 15:04"
+	exportBtn := widget.NewButtonWithIcon("", theme.DocumentSaveIcon(), func() {
+		now := time.Now()
+		fromEntry := widget.NewEntry()
+		fromEntry.SetText(now.Add(-24 * time.Hour).Format(exportTimeLayout))
+		toEntry := widget.NewEntry()
+		toEntry.SetText(now.Format(exportTimeLayout))
+
+		resLabels := []string{"Auto", "Live samples (in memory)"}
+		for _, res := range historyResolutions {
+			resLabels = append(resLabels, res.Name)
+		}
+		resSelect := widget.NewSelect(resLabels, nil)
+		resSelect.SetSelected(resLabels[0])
+		formatSelect := widget.NewSelect([]string{"CSV", "JSON Lines"}, nil)
+		formatSelect.SetSelected("CSV")
+
+		grid := container.NewGridWithColumns(2,
+			fieldLabel("From"), fromEntry,
+			fieldLabel("To"), toEntry,
+			fieldLabel("Resolution"), resSelect,
+			fieldLabel("Format"), formatSelect,
+		)
+		hint := widget.NewLabel("Times are local (YYYY-MM-DD HH:MM) and exported as UTC. Auto picks the finest resolution still kept for the start of the range.")
+		hint.Wrapping = fyne.TextWrapWord
+		hint.TextStyle = fyne.TextStyle{Italic: true}
+
+		d := dialog.NewCustomConfirm("Export history", "Export", "Cancel", container.NewVBox(grid, hint), func(ok bool) {
+			if !ok {
+				return
+			}
+			from, err := time.ParseInLocation(exportTimeLayout, strings.TrimSpace(fromEntry.Text), time.Local)
+			if err != nil {
+				dialog.ShowError(errors.New("invalid start time (YYYY-MM-DD HH:MM)"), w)
+				return
+			}
+			to, err := time.ParseInLocation(exportTimeLayout, strings.TrimSpace(toEntry.Text), time.Local)
+			if err != nil || !to.After(from) {
+				dialog.ShowError(errors.New("invalid end time (YYYY-MM-DD HH:MM, after the start)"), w)
+				return
+			}
+			// Include the minute the end time falls in.
+			to = to.Add(time.Minute)
+
+			var rows []exportRow
+			switch res := slices.Index(resLabels, resSelect.Selected) - 2; {
+			case res == exportLive:
+				times, mhs := hashrateHistory.Samples()
+				rows = exportRowsFromChart(times, mhs, from, to)
+			case hist == nil:
+				dialog.ShowError(fmt.Errorf("history is not available: %v", histErr), w)
+				return
+			default:
+				if res < 0 {
+					res = autoExportResolution(from, time.Now())
+				}
+				rows = exportRowsFromHistory(historyResolutions[res].Name, hist.Points(res, from, to))
+			}
+			if len(rows) == 0 {
+				dialog.ShowInformation("Export history", "No data recorded in that range.", w)
+				return
+			}
+
+			format, ext := exportCSV, ".csv"
+			if formatSelect.Selected == "JSON Lines" {
+				format, ext = exportJSONL, ".jsonl"
+			}
+			save := dialog.NewFileSave(func(wc fyne.URIWriteCloser, err error) {
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				if wc == nil {
+					return
+				}
+				err = writeExport(wc, format, rows)
+				if cerr := wc.Close(); err == nil {
+					err = cerr
+				}
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+				appendLog(fmt.Sprintf("[export] %d rows written to %s\n", len(rows), wc.URI().Path()))
+			}, w)
+			save.SetFileName("olivetum-history-" + from.Format("20060102") + ext)
+			save.Show()
+		}, w)
+		d.Resize(fyne.NewSize(520, 0))
+		d.Show()
+	})
+	exportBtn.Importance = widget.LowImportance
+
+	hashrate10mHeader := container.NewHBox(widget.NewIcon(theme.HistoryIcon()), hashrate10mTitle, layout.NewSpacer(), avgHashrateValue, historyRangeSelect, exportBtn)
 
 	statusBody := container.NewVBox(
 		fieldLabel("Total hashrate"),
@@ -817,7 +2819,13 @@ func main() {
 			metricTileWithIcon("Shares", theme.ConfirmIcon(), sharesValue),
 			metricTileWithIcon("Pool", theme.StorageIcon(), poolValue),
 		),
-		metricTileWithHeader(hashrate10mHeader, hashrateHistory.Object()),
+		instancesGrid,
+		controlRow,
+		gpus.Object(),
+		pools.Object(),
+		formRow("ethminer", minerVersionValue),
+		scheduleRow,
+		metricTileWithHeader(hashrate10mHeader, container.NewStack(hashrateHistory.Object(), historyChart.Object())),
 	)
 	statusPanel := panel("Dashboard", statusBody)
 
@@ -862,20 +2870,473 @@ func main() {
 		color.NRGBA{R: 0x0F, G: 0x17, B: 0x2A, A: 0xFF},
 		145,
 	)
-	main := container.NewBorder(container.NewVBox(header, widget.NewSeparator()), nil, nil, nil, container.NewPadded(mainSplit))
+	farm := newFarmView(w, cfg.Farm, func(rigs []FarmRig) {
+		cfg.Farm = rigs
+		if err := persistConfig(); err != nil {
+			appendLog(fmt.Sprintf("[config] %v\n", err))
+		}
+	})
+	go farm.Run(context.Background())
+
+	instanceID := newInstanceID()
+	discovery := newDiscoveryListener(instanceID)
+	farm.SetDiscovery(discovery.Peers)
+	// Announced rigs expire without a change notification.
+	go func() {
+		for range time.Tick(announceInterval) {
+			fyne.Do(farm.RefreshDiscovered)
+		}
+	}()
+
+	// applyLANSharing starts or stops the stats server, the announcer and
+	// the discovery listener to match cfg.LANShare; no LAN socket is open
+	// unless the user opted in. The announcement carries no secrets and the
+	// shared port only answers read-only stat calls.
+	var lanStop func()
+	applyLANSharing := func() {
+		if lanStop != nil {
+			// Close the sockets right away so sharing can be turned back
+			// on with the same ports.
+			lanStop()
+			lanStop = nil
+			discovery.Clear()
+			fyne.Do(farm.RefreshDiscovered)
+		}
+		if !cfg.LANShare {
+			return
+		}
+		ln, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.LANPort))
+		if err != nil {
+			appendLog(fmt.Sprintf("[lan] stats server: %v\n", err))
+			return
+		}
+		// Without the discovery port this rig is still announced and can
+		// be polled; it just won't see other rigs.
+		pc, err := net.ListenPacket("udp4", fmt.Sprintf(":%d", discoveryPort))
+		if err != nil {
+			appendLog(fmt.Sprintf("[lan] discovery: %v\n", err))
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		lanStop = func() {
+			cancel()
+			ln.Close()
+			if pc != nil {
+				pc.Close()
+			}
+		}
+		if pc != nil {
+			go func() {
+				err := discovery.Serve(ctx, pc, func() { fyne.Do(farm.RefreshDiscovered) })
+				if err != nil {
+					appendLog(fmt.Sprintf("[lan] discovery: %v\n", err))
+				}
+			}()
+		}
+		go serveStatsRPC(ln, func() (Stat, bool) {
+			lanMu.Lock()
+			defer lanMu.Unlock()
+			return lanStat, lanStatOK
+		})
+		rig := rigName()
+		msg := announcement{ID: instanceID, Rig: rig, APIPort: cfg.LANPort, Version: activeMiner.Version}
+		target := fmt.Sprintf("255.255.255.255:%d", discoveryPort)
+		go func() {
+			if err := announce(ctx, target, announceInterval, func() announcement { return msg }); err != nil {
+				appendLog(fmt.Sprintf("[lan] announce: %v\n", err))
+			}
+		}()
+		appendLog(fmt.Sprintf("[lan] sharing stats as %q on port %d\n", rig, cfg.LANPort))
+	}
+	lanShareCheck.OnChanged = func(on bool) {
+		if on {
+			port, err := strconv.Atoi(strings.TrimSpace(lanPortEntry.Text))
+			if strings.TrimSpace(lanPortEntry.Text) == "" {
+				port, err = defaultLANPort, nil
+			}
+			if err != nil || port < 1 || port > 65535 {
+				lanShareCheck.SetChecked(false)
+				dialog.ShowError(errors.New("invalid LAN stats port (1..65535)"), w)
+				return
+			}
+			cfg.LANPort = port
+			cfg.RigName = strings.TrimSpace(rigNameEntry.Text)
+		}
+		cfg.LANShare = on
+		if err := persistConfig(); err != nil {
+			appendLog(fmt.Sprintf("[config] %v\n", err))
+		}
+		applyLANSharing()
+	}
+	applyLANSharing()
+
+	// collectMetrics snapshots the run state for one metrics scrape.
+	collectMetrics := func() metricsSnapshot {
+		procMu.Lock()
+		defer procMu.Unlock()
+		snap := metricsSnapshot{State: minerStateStopped}
+		for _, inst := range instances {
+			snap.Instances = append(snap.Instances, inst.snapshot())
+		}
+		switch {
+		case startCancel != nil:
+			snap.State = minerStateStarting
+		case len(instances) == 0:
+		case slices.ContainsFunc(instances, (*minerInstance).running):
+			snap.State = minerStateRunning
+		default:
+			snap.State = minerStateRestarting
+		}
+		return snap
+	}
+
+	var metricsSrv *http.Server
+	applyMetrics = func() {
+		if metricsSrv != nil {
+			_ = metricsSrv.Close()
+			metricsSrv = nil
+		}
+		metricsApplied = cfg.Metrics
+		if !cfg.Metrics.Enabled {
+			return
+		}
+		ln, err := net.Listen("tcp", cfg.Metrics.addr())
+		if err != nil {
+			appendLog(fmt.Sprintf("[metrics] %v\n", err))
+			return
+		}
+		metricsSrv = serveMetrics(ln, collectMetrics)
+		appendLog(fmt.Sprintf("[metrics] serving http://%s/metrics\n", ln.Addr()))
+	}
+	metricsCheck.OnChanged = func(on bool) {
+		m, err := metricsFromUI()
+		if on && err != nil {
+			metricsCheck.SetChecked(false)
+			dialog.ShowError(err, w)
+			return
+		}
+		if err == nil {
+			cfg.Metrics = m
+		}
+		cfg.Metrics.Enabled = on
+		if err := persistConfig(); err != nil {
+			appendLog(fmt.Sprintf("[config] %v\n", err))
+		}
+		applyMetrics()
+	}
+	applyMetrics()
+
+	var pushCancel context.CancelFunc
+	applyPush = func() {
+		if pushCancel != nil {
+			pushCancel()
+			pushCancel = nil
+		}
+		sinks := pushSinks(cfg.Push)
+		if len(sinks) == 0 {
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		pushCancel = cancel
+		collect := func() (pushSample, bool) {
+			snap := collectMetrics()
+			s := pushSample{Time: time.Now(), Rig: rigName(), Worker: cfg.WorkerName}
+			for _, sn := range snap.Instances {
+				if sn.HasStat {
+					s.Instances = append(s.Instances, sn)
+				}
+			}
+			return s, len(s.Instances) > 0
+		}
+		go runPush(ctx, cfg.Push.interval(), sinks, collect, func(format string, args ...any) {
+			appendLog(fmt.Sprintf(format, args...))
+		})
+		var names []string
+		for _, s := range sinks {
+			names = append(names, s.Name())
+		}
+		appendLog(fmt.Sprintf("[push] sending to %s every %s\n", strings.Join(names, ", "), cfg.Push.interval()))
+	}
+	applyPush()
+
+	// settingsToUI loads s into the form so saveFromUI can validate and
+	// store it exactly as if it had been typed in.
+	settingsToUI := func(s remoteSettings) error {
+		modeLabel, ok := modeLabelForKey[s.Mode]
+		if !ok {
+			return fmt.Errorf("invalid mode %q", s.Mode)
+		}
+		backendLabel, ok := backendLabelForKey[s.Backend]
+		if !ok {
+			return fmt.Errorf("invalid backend %q", s.Backend)
+		}
+		restartLabel, ok := restartLabelForKey[s.RestartPolicy]
+		if !ok {
+			return fmt.Errorf("invalid restart policy %q", s.RestartPolicy)
+		}
+		modeSelect.SetSelected(modeLabel)
+		backendSelect.SetSelected(backendLabel)
+		hostEntry.SetText(s.StratumHost)
+		portEntry.SetText(strconv.Itoa(s.StratumPort))
+		rpcEntry.SetText(s.RPCURL)
+		walletEntry.SetText(s.WalletAddress)
+		workerEntry.SetText(s.WorkerName)
+		reportHashrateCheck.SetChecked(s.ReportHashrate)
+		displayIntervalEntry.SetText(strconv.Itoa(s.DisplayInterval))
+		pollIntervalEntry.SetText(strconv.Itoa(s.PollInterval))
+		restartSelect.SetSelected(restartLabel)
+		failoverEntry.SetText(strings.Join(s.FailoverPools, ", "))
+		startOnLaunchCheck.SetChecked(s.StartOnLaunch)
+		startupDelayEntry.SetText(strconv.Itoa(s.StartupDelay))
+		return nil
+	}
+
+	// onUI runs fn on the UI goroutine and returns its error.
+	onUI := func(fn func() error) error {
+		var err error
+		fyne.DoAndWait(func() { err = fn() })
+		return err
+	}
+	restStop := func() error {
+		if !isRunning() {
+			return errMinerStopped
+		}
+		appendLog("[rest] stop requested\n")
+		return onUI(func() error { stopMiner(); return nil })
+	}
+	actions := restActions{
+		Status: func() restStatus {
+			snap := collectMetrics()
+			devMu.Lock()
+			rows := gpuRowsFor(snap.Instances, devices)
+			devMu.Unlock()
+			return newRESTStatus(snap, rows)
+		},
+		Start: func() error {
+			appendLog("[rest] start requested\n")
+			return onUI(tryStartMiner)
+		},
+		Stop: restStop,
+		Restart: func() error {
+			if err := restStop(); err != nil {
+				return err
+			}
+			// Start again once every instance has exited, bounded like the
+			// quit path so a stuck stop hook can't wedge it.
+			deadline := time.Now().Add(2*cfg.Hooks.timeout() + 10*time.Second)
+			go func() {
+				for isRunning() && time.Now().Before(deadline) {
+					time.Sleep(200 * time.Millisecond)
+				}
+				if err := onUI(tryStartMiner); err != nil {
+					appendLog(fmt.Sprintf("[rest] restart failed: %v\n", err))
+				}
+			}()
+			return nil
+		},
+		GetConfig: func() remoteSettings {
+			var s remoteSettings
+			fyne.DoAndWait(func() { s = settingsOf(cfg) })
+			return s
+		},
+		PutConfig: func(s remoteSettings) error {
+			return onUI(func() error {
+				prev := settingsOf(cfg)
+				// The GPU selection is tied to the backend's device scan,
+				// which runs asynchronously; saving right after a switch
+				// would store the old backend's GPUs.
+				if s.Backend != prev.Backend {
+					return fmt.Errorf("backend cannot be changed over the REST API (currently %q); change it in the app", prev.Backend)
+				}
+				err := settingsToUI(s)
+				if err == nil {
+					err = saveFromUI()
+				}
+				if err != nil {
+					_ = settingsToUI(prev)
+					return err
+				}
+				appendLog("[rest] configuration updated\n")
+				return nil
+			})
+		},
+	}
+
+	// applyREST starts or stops the control API to match cfg.REST.
+	var restSrv *http.Server
+	applyREST := func() {
+		if restSrv != nil {
+			_ = restSrv.Close()
+			restSrv = nil
+		}
+		restTokenLabel.SetText(cfg.REST.Token)
+		if !cfg.REST.Enabled {
+			return
+		}
+		ln, err := net.Listen("tcp", cfg.REST.addr())
+		if err != nil {
+			appendLog(fmt.Sprintf("[rest] %v\n", err))
+			return
+		}
+		restSrv = serveREST(ln, cfg.REST.Token, actions)
+		appendLog(fmt.Sprintf("[rest] serving http://%s\n", ln.Addr()))
+	}
+	restCheck.OnChanged = func(on bool) {
+		if on {
+			port, err := strconv.Atoi(strings.TrimSpace(restPortEntry.Text))
+			if strings.TrimSpace(restPortEntry.Text) == "" {
+				port, err = defaultRESTPort, nil
+			}
+			if err != nil || port < 1 || port > 65535 {
+				restCheck.SetChecked(false)
+				dialog.ShowError(errors.New("invalid REST API port (1..65535)"), w)
+				return
+			}
+			cfg.REST.Port = port
+			if cfg.REST.Token == "" {
+				token, err := newAPIPassword()
+				if err != nil {
+					restCheck.SetChecked(false)
+					dialog.ShowError(err, w)
+					return
+				}
+				cfg.REST.Token = token
+			}
+		}
+		cfg.REST.Enabled = on
+		if err := persistConfig(); err != nil {
+			appendLog(fmt.Sprintf("[config] %v\n", err))
+		}
+		applyREST()
+	}
+	copyTokenBtn.OnTapped = func() {
+		if cfg.REST.Token != "" {
+			w.Clipboard().SetContent(cfg.REST.Token)
+		}
+	}
+	newTokenBtn.OnTapped = func() {
+		dialog.ShowConfirm("New REST API token", "Scripts using the current token will stop working. Continue?", func(ok bool) {
+			if !ok {
+				return
+			}
+			token, err := newAPIPassword()
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			cfg.REST.Token = token
+			if err := persistConfig(); err != nil {
+				appendLog(fmt.Sprintf("[config] %v\n", err))
+			}
+			applyREST()
+		}, w)
+	}
+	applyREST()
+
+	var (
+		mqttCancel context.CancelFunc
+		mqttDone   chan struct{}
+	)
+	applyMQTT = func() {
+		if mqttCancel != nil {
+			mqttCancel()
+			mqttCancel = nil
+		}
+		if !cfg.MQTT.Enabled {
+			return
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		mqttCancel = cancel
+		bridge := newMQTTBridge(cfg.MQTT, rigName(), mqttActions{
+			Status: actions.Status,
+			Start:  func() error { return onUI(tryStartMiner) },
+			Stop: func() error {
+				if !isRunning() {
+					return errMinerStopped
+				}
+				return onUI(func() error { stopMiner(); return nil })
+			},
+			PauseGPU: func(key gpuKey, pause bool) {
+				fyne.Do(func() { pauseGPU(key, pause) })
+			},
+		}, func(format string, args ...any) {
+			appendLog(fmt.Sprintf(format, args...))
+		})
+		// The old session publishes "offline" on its way out; let it finish
+		// before the new one reports "online".
+		prev, done := mqttDone, make(chan struct{})
+		mqttDone = done
+		go func() {
+			defer close(done)
+			if prev != nil {
+				<-prev
+			}
+			bridge.Run(ctx)
+		}()
+	}
+	applyMQTT()
+
+	tabs := container.NewAppTabs(
+		container.NewTabItemWithIcon("This rig", theme.ComputerIcon(), container.NewPadded(mainSplit)),
+		container.NewTabItemWithIcon("Farm", theme.GridIcon(), container.NewPadded(farm.Object())),
+		container.NewTabItemWithIcon("Sessions", theme.ListIcon(), container.NewPadded(sessionsTab.Object())),
+		container.NewTabItemWithIcon("Events", theme.HistoryIcon(), container.NewPadded(journalTab.Object())),
+	)
+	main := container.NewBorder(container.NewVBox(header, widget.NewSeparator()), nil, nil, nil, tabs)
 	w.SetContent(container.NewMax(bg, main))
 
 	if ethminerErr != nil {
-		dialog.ShowError(fmt.Errorf("ethminer not found. Place it next to this app or in PATH: %w", ethminerErr), w)
+		var untrusted *untrustedBinaryError
+		if errors.As(ethminerErr, &untrusted) {
+			handleEthminerErr(ethminerErr, nil)
+		} else {
+			dialog.ShowError(fmt.Errorf("%w\n\nPlace ethminer next to this app or in PATH, or pick a binary under Advanced options.", ethminerErr), w)
+		}
 	} else {
 		refreshDevices()
+
+		go func() {
+			<-devicesReady
+			ticker := time.NewTicker(10 * time.Second)
+			defer ticker.Stop()
+			for {
+				act := sched.Tick()
+				fyne.Do(func() {
+					applyScheduleAction(act, "schedule boundary")
+					refreshScheduleUI()
+				})
+				<-ticker.C
+			}
+		}()
+
+		if cfg.StartOnLaunch {
+			go func() {
+				<-devicesReady
+				if cfg.Schedule.Enabled {
+					appendLog("[autostart] schedule is enabled; leaving start/stop to the schedule\n")
+					return
+				}
+				if delay := time.Duration(cfg.StartupDelay) * time.Second; delay > 0 {
+					appendLog(fmt.Sprintf("[autostart] starting miner in %s\n", delay))
+					time.Sleep(delay)
+				}
+				fyne.Do(func() {
+					if isRunning() {
+						return
+					}
+					// Validate first so a broken config is reported in the log
+					// instead of an error dialog nobody is around to dismiss.
+					if err := saveFromUI(); err != nil {
+						appendLog(fmt.Sprintf("[autostart] aborted: %v\n", err))
+						return
+					}
+					startMiner()
+				})
+			}()
+		}
 	}
 
 	w.SetCloseIntercept(func() {
-		procMu.Lock()
-		running := minerCmd != nil && minerCmd.Process != nil
-		procMu.Unlock()
-		if !running {
+		if !isRunning() {
 			saveDraftFromUI()
 			w.Close()
 			return
@@ -884,9 +3345,16 @@ func main() {
 			if ok {
 				saveDraftFromUI()
 				stopMiner()
-				time.AfterFunc(500*time.Millisecond, func() {
+				// Wait for stop hooks and the miner to exit, bounded so a stuck
+				// hook can't keep the window open forever.
+				deadline := time.Now().Add(2*cfg.Hooks.timeout() + 10*time.Second)
+				go func() {
+					time.Sleep(500 * time.Millisecond)
+					for isRunning() && time.Now().Before(deadline) {
+						time.Sleep(200 * time.Millisecond)
+					}
 					fyne.Do(func() { w.Close() })
-				})
+				}()
 			}
 		}, w)
 	})
@@ -909,6 +3377,13 @@ func loadConfig() *Config {
 		SelectedDevices: nil,
 		ReportHashrate:  true,
 		DisplayInterval: 10,
+		PollInterval:    defaultPollInterval,
+		LANPort:         defaultLANPort,
+		Metrics:         MetricsConfig{Port: defaultMetricsPort},
+		REST:            RESTConfig{Port: defaultRESTPort},
+		RestartPolicy:   restartNever,
+		Notifications:   defaultNotifications(),
+		MQTT:            MQTTConfig{Discovery: true},
 	}
 	path, err := configPath()
 	if err != nil {
@@ -934,7 +3409,7 @@ func loadConfig() *Config {
 	if cfg.Backend == "" {
 		cfg.Backend = backendAuto
 	}
-	if cfg.Backend != backendAuto && cfg.Backend != backendCUDA && cfg.Backend != backendOpenCL {
+	if cfg.Backend != backendAuto && cfg.Backend != backendCUDA && cfg.Backend != backendOpenCL && cfg.Backend != backendMixed {
 		cfg.Backend = backendAuto
 	}
 	if cfg.RPCURL == "" {
@@ -943,6 +3418,47 @@ func loadConfig() *Config {
 	if cfg.DisplayInterval == 0 {
 		cfg.DisplayInterval = 10
 	}
+	if cfg.PollInterval < 1 || cfg.PollInterval > 60 {
+		cfg.PollInterval = defaultPollInterval
+	}
+	if cfg.LANPort < 1 || cfg.LANPort > 65535 {
+		cfg.LANPort = defaultLANPort
+	}
+	if cfg.Metrics.Port == 0 {
+		cfg.Metrics.Port = defaultMetricsPort
+	}
+	if cfg.Metrics.Validate() != nil {
+		cfg.Metrics = MetricsConfig{Port: defaultMetricsPort}
+	}
+	if cfg.REST.Port < 1 || cfg.REST.Port > 65535 {
+		cfg.REST.Port = defaultRESTPort
+	}
+	if cfg.REST.Token == "" {
+		cfg.REST.Enabled = false
+	}
+	if !validRestartPolicy(cfg.RestartPolicy) {
+		cfg.RestartPolicy = restartNever
+	}
+	if cfg.StartupDelay < 0 || cfg.StartupDelay > 600 {
+		cfg.StartupDelay = 0
+	}
+	if cfg.Hooks.TimeoutSec < 0 || cfg.Hooks.TimeoutSec > 600 {
+		cfg.Hooks.TimeoutSec = 0
+	}
+	if cfg.Alerts.Validate() != nil {
+		cfg.Alerts = AlertsConfig{}
+	}
+	cfg.Webhooks = slices.DeleteFunc(cfg.Webhooks, func(h WebhookConfig) bool { return h.Validate() != nil })
+	if cfg.MQTT.Enabled && cfg.MQTT.Validate() != nil {
+		cfg.MQTT.Enabled = false
+	}
+	if cfg.Push.Validate() != nil {
+		cfg.Push.Influx.Enabled, cfg.Push.StatsD.Enabled = false, false
+		cfg.Push.IntervalSec = 0
+	}
+	if cfg.Schedule.Validate() != nil {
+		cfg.Schedule.Enabled = false
+	}
 	return cfg
 }
 
@@ -958,7 +3474,12 @@ func saveConfig(cfg *Config) error {
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, b, 0o644)
+	// The config holds API passwords for farm rigs; tighten files created
+	// before that, too.
+	if err := os.WriteFile(path, b, 0o600); err != nil {
+		return err
+	}
+	return os.Chmod(path, 0o600)
 }
 
 func configPath() (string, error) {
@@ -1007,6 +3528,30 @@ func normalizeRPCURL(s string) (string, error) {
 	return u.String(), nil
 }
 
+func stratumUser(cfg *Config) string {
+	if cfg.WorkerName != "" {
+		return cfg.WalletAddress + "." + cfg.WorkerName
+	}
+	return cfg.WalletAddress
+}
+
+// buildPoolURLs returns the primary pool followed by the failover pools,
+// which only apply in stratum mode.
+func buildPoolURLs(cfg *Config) ([]string, error) {
+	primary, err := buildPoolURL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	urls := []string{primary}
+	if cfg.Mode != modeStratum {
+		return urls, nil
+	}
+	for _, hp := range cfg.FailoverPools {
+		urls = append(urls, fmt.Sprintf("stratum1+tcp://%s@%s", stratumUser(cfg), hp))
+	}
+	return urls, nil
+}
+
 func buildPoolURL(cfg *Config) (string, error) {
 	switch cfg.Mode {
 	case modeStratum:
@@ -1019,11 +3564,7 @@ func buildPoolURL(cfg *Config) (string, error) {
 		if !isHexAddress(cfg.WalletAddress) {
 			return "", errors.New("invalid wallet address (expected 0x + 40 hex chars)")
 		}
-		user := cfg.WalletAddress
-		if cfg.WorkerName != "" {
-			user = user + "." + cfg.WorkerName
-		}
-		return fmt.Sprintf("stratum1+tcp://%s@%s:%d", user, cfg.StratumHost, cfg.StratumPort), nil
+		return fmt.Sprintf("stratum1+tcp://%s@%s:%d", stratumUser(cfg), cfg.StratumHost, cfg.StratumPort), nil
 
 	case modeRPCLocal:
 		return normalizeRPCURL(cfg.RPCURL)
@@ -1118,9 +3659,10 @@ func listEthminerDevices(ethminerPath, backend string) ([]Device, string, error)
 		}
 		idx, _ := strconv.Atoi(m[1])
 		res = append(res, Device{
-			Index: idx,
-			PCI:   m[2],
-			Name:  strings.TrimSpace(m[3]),
+			Index:   idx,
+			PCI:     m[2],
+			Name:    strings.TrimSpace(m[3]),
+			Backend: backend,
 		})
 	}
 	if err := sc.Err(); err != nil {
@@ -1153,100 +3695,6 @@ func streamLines(r io.Reader, onLine func(string)) {
 	}
 }
 
-type apiResp struct {
-	Result json.RawMessage `json:"result"`
-	Error  any             `json:"error"`
-}
-
-func pollStats(ctx context.Context, host string, port int, onStat func(Stat), onErr func(error)) {
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
-
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			st, err := getStat1(host, port)
-			if err != nil {
-				onErr(err)
-				continue
-			}
-			onStat(st)
-		}
-	}
-}
-
-func getStat1(host string, port int) (Stat, error) {
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:%d", host, port), 1*time.Second)
-	if err != nil {
-		return Stat{}, err
-	}
-	defer conn.Close()
-	_ = conn.SetDeadline(time.Now().Add(1500 * time.Millisecond))
-
-	req := `{"id":1,"jsonrpc":"2.0","method":"miner_getstat1"}`
-	if _, err := io.WriteString(conn, req+"\n"); err != nil {
-		return Stat{}, err
-	}
-	line, err := bufio.NewReader(conn).ReadBytes('\n')
-	if err != nil {
-		return Stat{}, err
-	}
-
-	var resp apiResp
-	if err := json.Unmarshal(line, &resp); err != nil {
-		return Stat{}, err
-	}
-	if resp.Error != nil {
-		return Stat{}, fmt.Errorf("api error: %v", resp.Error)
-	}
-	var arr []string
-	if err := json.Unmarshal(resp.Result, &arr); err != nil {
-		return Stat{}, err
-	}
-	if len(arr) < 9 {
-		return Stat{}, fmt.Errorf("unexpected stat format (%d items)", len(arr))
-	}
-
-	st := Stat{Version: arr[0]}
-	st.UptimeMin, _ = strconv.Atoi(arr[1])
-
-	// "kh;accepted;rejected"
-	if parts := strings.Split(arr[2], ";"); len(parts) >= 3 {
-		st.TotalKHs, _ = strconv.ParseInt(parts[0], 10, 64)
-		st.Accepted, _ = strconv.ParseInt(parts[1], 10, 64)
-		st.Rejected, _ = strconv.ParseInt(parts[2], 10, 64)
-	}
-
-	// "kh1;kh2;..."
-	if parts := strings.Split(arr[3], ";"); len(parts) > 0 && parts[0] != "" {
-		for _, p := range parts {
-			v, _ := strconv.ParseInt(p, 10, 64)
-			st.PerGPU_KHs = append(st.PerGPU_KHs, v)
-		}
-	}
-
-	// temps/fans pairs
-	if parts := strings.Split(arr[6], ";"); len(parts) >= 2 {
-		for i := 0; i+1 < len(parts); i += 2 {
-			t, _ := strconv.Atoi(parts[i])
-			f, _ := strconv.Atoi(parts[i+1])
-			st.Temps = append(st.Temps, t)
-			st.Fans = append(st.Fans, f)
-		}
-	}
-
-	st.Pool = arr[7]
-
-	// "ethInvalid;ethSwitches;dcrInvalid;dcrSwitches"
-	if parts := strings.Split(arr[8], ";"); len(parts) >= 2 {
-		st.Invalid, _ = strconv.ParseInt(parts[0], 10, 64)
-		st.PoolSwitches, _ = strconv.ParseInt(parts[1], 10, 64)
-	}
-	return st, nil
-}
-
 var ansiCSI = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
 
 func sanitizeLogLine(s string) string {
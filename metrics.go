@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMetricsBind = "127.0.0.1"
+	defaultMetricsPort = 9478
+
+	minerStateStopped    = "stopped"
+	minerStateStarting   = "starting"
+	minerStateRunning    = "running"
+	minerStateRestarting = "restarting"
+)
+
+var minerStates = []string{minerStateStopped, minerStateStarting, minerStateRunning, minerStateRestarting}
+
+// MetricsConfig controls the Prometheus /metrics endpoint.
+type MetricsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Bind    string `json:"bind,omitempty"`
+	Port    int    `json:"port,omitempty"`
+}
+
+func (m MetricsConfig) Validate() error {
+	if m.Bind != "" && net.ParseIP(m.Bind) == nil {
+		return fmt.Errorf("invalid metrics bind address %q (IP address expected)", m.Bind)
+	}
+	if m.Port < 1 || m.Port > 65535 {
+		return fmt.Errorf("invalid metrics port (1..65535)")
+	}
+	return nil
+}
+
+func (m MetricsConfig) addr() string {
+	bind := m.Bind
+	if bind == "" {
+		bind = defaultMetricsBind
+	}
+	return net.JoinHostPort(bind, strconv.Itoa(m.Port))
+}
+
+// metricsSnapshot is what one scrape reports: the miner state and the
+// instances with the last Stat their pollers produced.
+type metricsSnapshot struct {
+	State     string
+	Instances []instanceSnapshot
+}
+
+// metricFamily is one metric name with its samples, rendered in the
+// Prometheus text exposition format.
+type metricFamily struct {
+	name, help, typ string
+	samples         []string
+}
+
+func (f *metricFamily) add(value float64, labels ...string) {
+	var b strings.Builder
+	b.WriteString(f.name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(&b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	f.samples = append(f.samples, b.String())
+}
+
+func escapeLabel(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+// renderMetrics builds the /metrics body for snap.
+func renderMetrics(snap metricsSnapshot) []byte {
+	var (
+		state    = &metricFamily{name: "olivetum_miner_state", help: "Current miner state (1 for the active state).", typ: "gauge"}
+		up       = &metricFamily{name: "olivetum_miner_up", help: "Whether the ethminer process of an instance is running.", typ: "gauge"}
+		restarts = &metricFamily{name: "olivetum_miner_restarts_total", help: "Automatic restarts of an instance since mining was started.", typ: "counter"}
+		uptime   = &metricFamily{name: "olivetum_miner_uptime_seconds", help: "ethminer uptime.", typ: "gauge"}
+		hashrate = &metricFamily{name: "olivetum_miner_hashrate", help: "Total hashrate in H/s.", typ: "gauge"}
+		shares   = &metricFamily{name: "olivetum_miner_shares_total", help: "Shares by result.", typ: "counter"}
+		switches = &metricFamily{name: "olivetum_miner_pool_switches_total", help: "Pool switches reported by ethminer.", typ: "counter"}
+		gpuHash  = &metricFamily{name: "olivetum_gpu_hashrate", help: "Per-GPU hashrate in H/s.", typ: "gauge"}
+		gpuTemp  = &metricFamily{name: "olivetum_gpu_temperature_celsius", help: "Per-GPU temperature.", typ: "gauge"}
+		gpuFan   = &metricFamily{name: "olivetum_gpu_fan_percent", help: "Per-GPU fan speed.", typ: "gauge"}
+	)
+
+	for _, s := range minerStates {
+		v := 0.0
+		if s == snap.State {
+			v = 1
+		}
+		state.add(v, "state", s)
+	}
+
+	for _, sn := range snap.Instances {
+		inst := sn.Name
+		up.add(boolFloat(sn.Running), "instance", inst)
+		restarts.add(float64(sn.Restarts), "instance", inst)
+		if !sn.HasStat {
+			continue
+		}
+		st := sn.Stat
+		uptime.add(float64(st.UptimeMin*60), "instance", inst)
+		hashrate.add(float64(st.TotalKHs*1000), "instance", inst)
+		shares.add(float64(st.Accepted), "instance", inst, "result", "accepted")
+		shares.add(float64(st.Rejected), "instance", inst, "result", "rejected")
+		shares.add(float64(st.Invalid), "instance", inst, "result", "invalid")
+		switches.add(float64(st.PoolSwitches), "instance", inst)
+
+		if len(st.Devices) > 0 {
+			for _, d := range st.Devices {
+				gpu := strconv.Itoa(d.Index)
+				gpuHash.add(float64(d.KHs*1000), "instance", inst, "gpu", gpu, "name", d.Name)
+				gpuTemp.add(float64(d.Temp), "instance", inst, "gpu", gpu, "name", d.Name)
+				gpuFan.add(float64(d.Fan), "instance", inst, "gpu", gpu, "name", d.Name)
+			}
+			continue
+		}
+		for i, kh := range st.PerGPU_KHs {
+			gpu := strconv.Itoa(i)
+			gpuHash.add(float64(kh*1000), "instance", inst, "gpu", gpu)
+			if i < len(st.Temps) {
+				gpuTemp.add(float64(st.Temps[i]), "instance", inst, "gpu", gpu)
+			}
+			if i < len(st.Fans) {
+				gpuFan.add(float64(st.Fans[i]), "instance", inst, "gpu", gpu)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, f := range []*metricFamily{state, up, restarts, uptime, hashrate, shares, switches, gpuHash, gpuTemp, gpuFan} {
+		if len(f.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ)
+		for _, s := range f.samples {
+			buf.WriteString(s)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes()
+}
+
+func boolFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// serveMetrics serves /metrics on ln until the returned server is closed.
+func serveMetrics(ln net.Listener, collect func() metricsSnapshot) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_, _ = w.Write(renderMetrics(collect()))
+	})
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return srv
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// A minimal MQTT 3.1.1 client: QoS 0 publish and subscribe, a last will,
+// and keepalive pings. That is all the telemetry bridge needs.
+
+const (
+	mqttConnect    = 0x10
+	mqttConnAck    = 0x20
+	mqttPublish    = 0x30
+	mqttPubAck     = 0x40
+	mqttSubscribe  = 0x82
+	mqttSubAck     = 0x90
+	mqttPingReq    = 0xc0
+	mqttPingResp   = 0xd0
+	mqttDisconnect = 0xe0
+
+	mqttMaxPacket = 256 << 10
+	// mqttWriteTimeout bounds every write so a broker that stops reading
+	// can't block publishing or shutdown.
+	mqttWriteTimeout = 10 * time.Second
+)
+
+type mqttOptions struct {
+	Addr      string
+	TLS       bool
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration
+
+	WillTopic   string
+	WillPayload []byte
+	WillRetain  bool
+}
+
+type mqttClient struct {
+	conn      net.Conn
+	r         *bufio.Reader
+	keepAlive time.Duration
+
+	wmu    sync.Mutex
+	nextID uint16
+
+	// pending holds packets read while waiting for a SUBACK; Serve handles
+	// them first.
+	pending []mqttPacket
+}
+
+type mqttPacket struct {
+	typ  byte
+	body []byte
+}
+
+var mqttConnectErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// dialMQTT connects and waits for the broker to accept the session.
+func dialMQTT(ctx context.Context, opts mqttOptions) (*mqttClient, error) {
+	var (
+		conn net.Conn
+		err  error
+	)
+	d := &net.Dialer{Timeout: 10 * time.Second}
+	if opts.TLS {
+		host, _, _ := net.SplitHostPort(opts.Addr)
+		conn, err = (&tls.Dialer{NetDialer: d, Config: &tls.Config{ServerName: host}}).DialContext(ctx, "tcp", opts.Addr)
+	} else {
+		conn, err = d.DialContext(ctx, "tcp", opts.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &mqttClient{conn: conn, r: bufio.NewReader(conn), keepAlive: opts.KeepAlive}
+
+	var body []byte
+	body = appendMQTTString(body, "MQTT")
+	body = append(body, 4) // protocol level 3.1.1
+	flags := byte(0x02)    // clean session
+	if opts.WillTopic != "" {
+		flags |= 0x04
+		if opts.WillRetain {
+			flags |= 0x20
+		}
+	}
+	if opts.Username != "" {
+		flags |= 0x80
+		if opts.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(opts.KeepAlive/time.Second))
+	body = appendMQTTString(body, opts.ClientID)
+	if opts.WillTopic != "" {
+		body = appendMQTTString(body, opts.WillTopic)
+		body = appendMQTTBytes(body, opts.WillPayload)
+	}
+	if opts.Username != "" {
+		body = appendMQTTString(body, opts.Username)
+		if opts.Password != "" {
+			body = appendMQTTString(body, opts.Password)
+		}
+	}
+
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if err := c.write(mqttConnect, body); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	typ, ack, err := c.read()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if typ&0xf0 != mqttConnAck || len(ack) != 2 {
+		conn.Close()
+		return nil, fmt.Errorf("unexpected reply to CONNECT (packet type %#x)", typ)
+	}
+	if ack[1] != 0 {
+		conn.Close()
+		if msg, ok := mqttConnectErrors[ack[1]]; ok {
+			return nil, fmt.Errorf("connection refused: %s", msg)
+		}
+		return nil, fmt.Errorf("connection refused (code %d)", ack[1])
+	}
+	if !stop() {
+		return nil, ctx.Err()
+	}
+	_ = conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+func appendMQTTString(b []byte, s string) []byte {
+	return appendMQTTBytes(b, []byte(s))
+}
+
+func appendMQTTBytes(b, v []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(v)))
+	return append(b, v...)
+}
+
+func (c *mqttClient) write(header byte, body []byte) error {
+	return c.writeWithin(mqttWriteTimeout, header, body)
+}
+
+func (c *mqttClient) writeWithin(timeout time.Duration, header byte, body []byte) error {
+	pkt := []byte{header}
+	n := len(body)
+	for {
+		d := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			d |= 0x80
+		}
+		pkt = append(pkt, d)
+		if n == 0 {
+			break
+		}
+	}
+	pkt = append(pkt, body...)
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(timeout))
+	_, err := c.conn.Write(pkt)
+	return err
+}
+
+func (c *mqttClient) read() (byte, []byte, error) {
+	typ, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, mult := 0, 1
+	for i := 0; ; i++ {
+		d, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		if i == 4 {
+			return 0, nil, errors.New("malformed packet length")
+		}
+		n += int(d&0x7f) * mult
+		mult *= 128
+		if d&0x80 == 0 {
+			break
+		}
+	}
+	if n > mqttMaxPacket {
+		return 0, nil, fmt.Errorf("packet too large (%d bytes)", n)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return typ, body, nil
+}
+
+// Publish sends payload at QoS 0.
+func (c *mqttClient) Publish(topic string, payload []byte, retain bool) error {
+	header := byte(mqttPublish)
+	if retain {
+		header |= 0x01
+	}
+	body := appendMQTTString(nil, topic)
+	return c.write(header, append(body, payload...))
+}
+
+// Subscribe subscribes to filters at QoS 0 and waits for the SUBACK. It must
+// be called before Serve.
+func (c *mqttClient) Subscribe(filters ...string) error {
+	c.nextID++
+	if c.nextID == 0 {
+		c.nextID = 1
+	}
+	id := c.nextID
+	body := binary.BigEndian.AppendUint16(nil, id)
+	for _, f := range filters {
+		body = appendMQTTString(body, f)
+		body = append(body, 0)
+	}
+	_ = c.conn.SetDeadline(time.Now().Add(10 * time.Second))
+	defer c.conn.SetDeadline(time.Time{})
+	if err := c.write(mqttSubscribe, body); err != nil {
+		return err
+	}
+	for {
+		typ, ack, err := c.read()
+		if err != nil {
+			return err
+		}
+		if typ&0xf0 != mqttSubAck {
+			// Retained messages for an earlier filter may come first.
+			c.pending = append(c.pending, mqttPacket{typ, ack})
+			continue
+		}
+		if len(ack) < 2 || binary.BigEndian.Uint16(ack) != id {
+			return errors.New("unexpected SUBACK")
+		}
+		for i, code := range ack[2:] {
+			if code == 0x80 && i < len(filters) {
+				return fmt.Errorf("subscription to %q refused", filters[i])
+			}
+		}
+		return nil
+	}
+}
+
+// Serve reads incoming messages until the connection fails or is closed,
+// pinging the broker to keep the session alive. retained is set for stored
+// messages delivered because of a new subscription.
+func (c *mqttClient) Serve(onMessage func(topic string, payload []byte, retained bool)) error {
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		t := time.NewTicker(c.keepAlive / 2)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				if c.write(mqttPingReq, nil) != nil {
+					return
+				}
+			}
+		}
+	}()
+	pending := c.pending
+	c.pending = nil
+	for _, p := range pending {
+		if err := c.dispatch(p.typ, p.body, onMessage); err != nil {
+			return err
+		}
+	}
+	for {
+		// The broker answers pings, so silence for a whole keepalive
+		// period means the connection is gone.
+		_ = c.conn.SetReadDeadline(time.Now().Add(c.keepAlive + c.keepAlive/2))
+		typ, body, err := c.read()
+		if err != nil {
+			return err
+		}
+		if err := c.dispatch(typ, body, onMessage); err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch passes an incoming PUBLISH to onMessage and ignores other packets.
+func (c *mqttClient) dispatch(typ byte, body []byte, onMessage func(topic string, payload []byte, retained bool)) error {
+	if typ&0xf0 != mqttPublish {
+		return nil
+	}
+	if len(body) < 2 {
+		return errors.New("malformed PUBLISH")
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	if len(body) < 2+n {
+		return errors.New("malformed PUBLISH")
+	}
+	topic, rest := string(body[2:2+n]), body[2+n:]
+	if qos := (typ >> 1) & 0x03; qos > 0 {
+		if len(rest) < 2 {
+			return errors.New("malformed PUBLISH")
+		}
+		if qos == 1 {
+			_ = c.write(mqttPubAck, rest[:2])
+		}
+		rest = rest[2:]
+	}
+	onMessage(topic, rest, typ&0x01 != 0)
+	return nil
+}
+
+// Close disconnects cleanly; the broker does not publish the will.
+func (c *mqttClient) Close() {
+	_ = c.writeWithin(time.Second, mqttDisconnect, nil)
+	c.conn.Close()
+}
@@ -0,0 +1,412 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMQTTTopicPrefix     = "olivetum"
+	defaultMQTTDiscoveryPrefix = "homeassistant"
+
+	mqttKeepAlive       = 30 * time.Second
+	mqttPublishInterval = 10 * time.Second
+	mqttMaxRetryDelay   = time.Minute
+)
+
+// MQTTConfig controls the MQTT telemetry bridge.
+type MQTTConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Broker          string `json:"broker,omitempty"`
+	TLS             bool   `json:"tls,omitempty"`
+	Username        string `json:"username,omitempty"`
+	Password        string `json:"password,omitempty"`
+	TopicPrefix     string `json:"topicPrefix,omitempty"`
+	Discovery       bool   `json:"discovery"`
+	DiscoveryPrefix string `json:"discoveryPrefix,omitempty"`
+}
+
+func (m MQTTConfig) Validate() error {
+	host, port, err := net.SplitHostPort(m.Broker)
+	if n, perr := strconv.Atoi(port); err != nil || host == "" || perr != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid MQTT broker %q (host:port)", m.Broker)
+	}
+	for _, p := range []string{m.TopicPrefix, m.DiscoveryPrefix} {
+		if strings.ContainsAny(p, "#+") {
+			return fmt.Errorf("invalid MQTT topic prefix %q", p)
+		}
+	}
+	return nil
+}
+
+func (m MQTTConfig) topicPrefix() string {
+	if p := strings.Trim(m.TopicPrefix, "/"); p != "" {
+		return p
+	}
+	return defaultMQTTTopicPrefix
+}
+
+func (m MQTTConfig) discoveryPrefix() string {
+	if p := strings.Trim(m.DiscoveryPrefix, "/"); p != "" {
+		return p
+	}
+	return defaultMQTTDiscoveryPrefix
+}
+
+// mqttNodeID turns a rig name into a topic segment and Home Assistant id.
+func mqttNodeID(rig string) string {
+	id := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		default:
+			return '_'
+		}
+	}, rig)
+	if id == "" {
+		return "rig"
+	}
+	return id
+}
+
+// mqttGPUID names a GPU in topics, e.g. "cuda-0".
+func mqttGPUID(d restDevice) string {
+	return fmt.Sprintf("%s-%d", d.Instance, d.Index)
+}
+
+// mqttActions is what the bridge can do to the miner.
+type mqttActions struct {
+	Status   func() restStatus
+	Start    func() error
+	Stop     func() error
+	PauseGPU func(key gpuKey, pause bool)
+}
+
+// mqttBridge publishes miner state as retained topics and routes command
+// topics to the miner.
+type mqttBridge struct {
+	cfg  MQTTConfig
+	rig  string
+	node string
+	base string
+	act  mqttActions
+	logf func(format string, args ...any)
+
+	client     *mqttClient
+	published  map[string]string
+	discovered map[string]bool
+}
+
+func newMQTTBridge(cfg MQTTConfig, rig string, act mqttActions, logf func(format string, args ...any)) *mqttBridge {
+	node := mqttNodeID(rig)
+	return &mqttBridge{
+		cfg:  cfg,
+		rig:  rig,
+		node: node,
+		base: cfg.topicPrefix() + "/" + node,
+		act:  act,
+		logf: logf,
+	}
+}
+
+func (b *mqttBridge) topic(parts ...string) string {
+	return b.base + "/" + strings.Join(parts, "/")
+}
+
+// Run keeps a broker session up until ctx is done, reconnecting with
+// backoff.
+func (b *mqttBridge) Run(ctx context.Context) {
+	delay := time.Second
+	lastErr := ""
+	for ctx.Err() == nil {
+		connected, err := b.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if msg := err.Error(); msg != lastErr {
+			lastErr = msg
+			b.logf("[mqtt] %v; reconnecting\n", err)
+		}
+		// Back off only while connecting keeps failing.
+		if connected {
+			delay = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, mqttMaxRetryDelay)
+	}
+}
+
+// session runs one broker connection until it fails or ctx is done.
+// connected reports whether it got as far as subscribing and going online.
+func (b *mqttBridge) session(ctx context.Context) (connected bool, err error) {
+	c, err := dialMQTT(ctx, mqttOptions{
+		Addr:        b.cfg.Broker,
+		TLS:         b.cfg.TLS,
+		ClientID:    "olivetum-" + b.node,
+		Username:    b.cfg.Username,
+		Password:    b.cfg.Password,
+		KeepAlive:   mqttKeepAlive,
+		WillTopic:   b.topic("availability"),
+		WillPayload: []byte("offline"),
+		WillRetain:  true,
+	})
+	if err != nil {
+		return false, err
+	}
+	b.client = c
+	b.published = map[string]string{}
+	b.discovered = map[string]bool{}
+	if err := c.Subscribe(b.topic("command", "+"), b.topic("gpu", "+", "pause", "set")); err != nil {
+		c.Close()
+		return false, err
+	}
+	if err := c.Publish(b.topic("availability"), []byte("online"), true); err != nil {
+		c.Close()
+		return false, err
+	}
+	b.logf("[mqtt] connected to %s as %s\n", b.cfg.Broker, b.base)
+
+	// Start and stop wait for the UI, so commands run off the read loop, one
+	// at a time and in the order they arrived.
+	commands := make(chan [2]string, 16)
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case cmd := <-commands:
+				b.handle(cmd[0], cmd[1])
+			}
+		}
+	}()
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- c.Serve(func(topic string, payload []byte, retained bool) {
+			// A command left retained on the broker would replay on every
+			// reconnect.
+			if retained {
+				b.logf("[mqtt] ignoring retained message on %s\n", topic)
+				return
+			}
+			select {
+			case commands <- [2]string{topic, string(payload)}:
+			default:
+				b.logf("[mqtt] dropping command on %s: too many pending\n", topic)
+			}
+		})
+	}()
+
+	ticker := time.NewTicker(mqttPublishInterval)
+	defer ticker.Stop()
+	for {
+		if err := b.publishStatus(); err != nil {
+			c.Close()
+			return true, err
+		}
+		select {
+		case <-ctx.Done():
+			_ = c.Publish(b.topic("availability"), []byte("offline"), true)
+			c.Close()
+			return true, ctx.Err()
+		case err := <-serveErr:
+			c.Close()
+			return true, err
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish sends a retained value if it changed since the last publish.
+func (b *mqttBridge) publish(topic, value string) error {
+	if last, ok := b.published[topic]; ok && last == value {
+		return nil
+	}
+	if err := b.client.Publish(topic, []byte(value), true); err != nil {
+		return err
+	}
+	b.published[topic] = value
+	return nil
+}
+
+func (b *mqttBridge) publishStatus() error {
+	st := b.act.Status()
+	if b.cfg.Discovery {
+		if err := b.publishDiscovery(st); err != nil {
+			return err
+		}
+	}
+	mhs := func(hs int64) string { return strconv.FormatFloat(float64(hs)/1e6, 'f', 2, 64) }
+	values := [][2]string{
+		{b.topic("status"), st.State},
+		{b.topic("hashrate"), mhs(st.Hashrate)},
+		{b.topic("shares", "accepted"), strconv.FormatInt(st.Accepted, 10)},
+		{b.topic("shares", "rejected"), strconv.FormatInt(st.Rejected, 10)},
+		{b.topic("shares", "invalid"), strconv.FormatInt(st.Invalid, 10)},
+		{b.topic("pool"), st.Pool},
+	}
+	for _, d := range st.Devices {
+		id := mqttGPUID(d)
+		paused := "OFF"
+		if d.Paused {
+			paused = "ON"
+		}
+		values = append(values,
+			[2]string{b.topic("gpu", id, "hashrate"), mhs(d.Hashrate)},
+			[2]string{b.topic("gpu", id, "temperature"), strconv.Itoa(d.Temp)},
+			[2]string{b.topic("gpu", id, "fan"), strconv.Itoa(d.Fan)},
+			[2]string{b.topic("gpu", id, "paused"), paused},
+		)
+	}
+	for _, v := range values {
+		if err := b.publish(v[0], v[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// publishDiscovery announces the rig entities once per session and each
+// GPU when it first reports stats.
+func (b *mqttBridge) publishDiscovery(st restStatus) error {
+	device := map[string]any{
+		"identifiers":  []string{"olivetum_" + b.node},
+		"name":         b.rig,
+		"manufacturer": "Olivetum",
+		"model":        appName,
+	}
+	avail := b.topic("availability")
+	announce := func(component, object string, cfg map[string]any) error {
+		key := component + "/" + object
+		if b.discovered[key] {
+			return nil
+		}
+		cfg["unique_id"] = "olivetum_" + b.node + "_" + object
+		cfg["object_id"] = "olivetum_" + b.node + "_" + object
+		cfg["availability_topic"] = avail
+		cfg["device"] = device
+		payload, _ := json.Marshal(cfg)
+		topic := fmt.Sprintf("%s/%s/%s/%s/config", b.cfg.discoveryPrefix(), component, b.node, object)
+		if err := b.client.Publish(topic, payload, true); err != nil {
+			return err
+		}
+		b.discovered[key] = true
+		return nil
+	}
+	sensor := func(object, name, state string, extra map[string]any) error {
+		cfg := map[string]any{"name": name, "state_topic": state}
+		for k, v := range extra {
+			cfg[k] = v
+		}
+		return announce("sensor", object, cfg)
+	}
+	shares := map[string]any{"state_class": "total_increasing", "icon": "mdi:check-decagram"}
+	rate := map[string]any{"unit_of_measurement": "MH/s", "state_class": "measurement", "icon": "mdi:pickaxe"}
+	entities := []func() error{
+		func() error {
+			return sensor("status", "Status", b.topic("status"), map[string]any{"icon": "mdi:state-machine"})
+		},
+		func() error { return sensor("hashrate", "Hashrate", b.topic("hashrate"), rate) },
+		func() error { return sensor("accepted", "Accepted shares", b.topic("shares", "accepted"), shares) },
+		func() error { return sensor("rejected", "Rejected shares", b.topic("shares", "rejected"), shares) },
+		func() error { return sensor("invalid", "Invalid shares", b.topic("shares", "invalid"), shares) },
+		func() error {
+			return sensor("pool", "Pool", b.topic("pool"), map[string]any{"icon": "mdi:server-network"})
+		},
+		func() error {
+			return announce("button", "start", map[string]any{"name": "Start mining", "command_topic": b.topic("command", "start"), "icon": "mdi:play"})
+		},
+		func() error {
+			return announce("button", "stop", map[string]any{"name": "Stop mining", "command_topic": b.topic("command", "stop"), "icon": "mdi:stop"})
+		},
+	}
+	for _, d := range st.Devices {
+		id := mqttGPUID(d)
+		object := "gpu_" + strings.ReplaceAll(id, "-", "_")
+		label := fmt.Sprintf("GPU %s %d", backendDisplayName(d.Instance), d.Index)
+		entities = append(entities,
+			func() error {
+				return sensor(object+"_hashrate", label+" hashrate", b.topic("gpu", id, "hashrate"), rate)
+			},
+			func() error {
+				return sensor(object+"_temperature", label+" temperature", b.topic("gpu", id, "temperature"), map[string]any{
+					"device_class": "temperature", "unit_of_measurement": "°C", "state_class": "measurement",
+				})
+			},
+			func() error {
+				return sensor(object+"_fan", label+" fan", b.topic("gpu", id, "fan"), map[string]any{
+					"unit_of_measurement": "%", "state_class": "measurement", "icon": "mdi:fan",
+				})
+			},
+			func() error {
+				return announce("switch", object+"_pause", map[string]any{
+					"name": label + " paused", "state_topic": b.topic("gpu", id, "paused"),
+					"command_topic": b.topic("gpu", id, "pause", "set"), "icon": "mdi:pause",
+				})
+			},
+		)
+	}
+	for _, e := range entities {
+		if err := e(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handle runs one command message.
+func (b *mqttBridge) handle(topic, payload string) {
+	rest, ok := strings.CutPrefix(topic, b.base+"/")
+	if !ok {
+		return
+	}
+	parts := strings.Split(rest, "/")
+	switch {
+	case len(parts) == 2 && parts[0] == "command":
+		var err error
+		switch parts[1] {
+		case "start":
+			b.logf("[mqtt] start requested\n")
+			err = b.act.Start()
+		case "stop":
+			b.logf("[mqtt] stop requested\n")
+			err = b.act.Stop()
+		default:
+			b.logf("[mqtt] unknown command %q\n", parts[1])
+			return
+		}
+		if err != nil {
+			b.logf("[mqtt] %s: %v\n", parts[1], err)
+		}
+	case len(parts) == 4 && parts[0] == "gpu" && parts[2] == "pause" && parts[3] == "set":
+		// Instance names may contain "-"; the index follows the last one.
+		i := strings.LastIndex(parts[1], "-")
+		n, err := strconv.Atoi(parts[1][i+1:])
+		if i <= 0 || err != nil {
+			b.logf("[mqtt] unknown GPU %q\n", parts[1])
+			return
+		}
+		var pause bool
+		switch strings.ToUpper(strings.TrimSpace(payload)) {
+		case "ON", "PAUSE", "1", "TRUE":
+			pause = true
+		case "OFF", "RESUME", "0", "FALSE":
+		default:
+			b.logf("[mqtt] invalid pause payload %q for GPU %s\n", payload, parts[1])
+			return
+		}
+		b.act.PauseGPU(gpuKey{Instance: parts[1][:i], Index: n}, pause)
+	}
+}
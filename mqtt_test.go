@@ -0,0 +1,480 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBroker accepts MQTT connections on loopback. The test drives the
+// broker side of each connection by hand.
+func fakeBroker(t *testing.T) (addr string, accept func() *mqttClient) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	conns := make(chan net.Conn, 4)
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				close(conns)
+				return
+			}
+			conns <- c
+		}
+	}()
+	return ln.Addr().String(), func() *mqttClient {
+		t.Helper()
+		select {
+		case c := <-conns:
+			t.Cleanup(func() { c.Close() })
+			_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+			return &mqttClient{conn: c, r: bufio.NewReader(c)}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a connection")
+			return nil
+		}
+	}
+}
+
+func expectPacket(t *testing.T, srv *mqttClient, want byte) []byte {
+	t.Helper()
+	typ, body, err := srv.read()
+	if err != nil {
+		t.Fatalf("broker read: %v", err)
+	}
+	if typ != want {
+		t.Fatalf("broker got packet %#x, want %#x", typ, want)
+	}
+	return body
+}
+
+func publishBody(topic, payload string) []byte {
+	return append(appendMQTTString(nil, topic), payload...)
+}
+
+func splitPublish(t *testing.T, body []byte) (string, string) {
+	t.Helper()
+	if len(body) < 2 {
+		t.Fatalf("short PUBLISH %q", body)
+	}
+	n := int(binary.BigEndian.Uint16(body))
+	return string(body[2 : 2+n]), string(body[2+n:])
+}
+
+// connectClient dials the broker and answers the CONNECT with code.
+func connectClient(t *testing.T, opts mqttOptions, code byte) (c *mqttClient, srv *mqttClient, connect []byte, err error) {
+	t.Helper()
+	addr, accept := fakeBroker(t)
+	opts.Addr = addr
+	type result struct {
+		c   *mqttClient
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		c, err := dialMQTT(context.Background(), opts)
+		done <- result{c, err}
+	}()
+	srv = accept()
+	connect = expectPacket(t, srv, mqttConnect)
+	if err := srv.write(mqttConnAck, []byte{0, code}); err != nil {
+		t.Fatal(err)
+	}
+	r := <-done
+	if r.c != nil {
+		t.Cleanup(func() { r.c.conn.Close() })
+	}
+	return r.c, srv, connect, r.err
+}
+
+func TestMQTTConnect(t *testing.T) {
+	_, _, got, err := connectClient(t, mqttOptions{
+		ClientID:    "c1",
+		Username:    "u",
+		Password:    "p",
+		KeepAlive:   30 * time.Second,
+		WillTopic:   "t/avail",
+		WillPayload: []byte("offline"),
+		WillRetain:  true,
+	}, 0)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	var want []byte
+	want = append(want, 0, 4, 'M', 'Q', 'T', 'T', 4)
+	// user name, password, will retain, will, clean session
+	want = append(want, 0x80|0x40|0x20|0x04|0x02)
+	want = append(want, 0, 30)
+	want = append(want, 0, 2, 'c', '1')
+	want = append(want, 0, 7)
+	want = append(want, "t/avail"...)
+	want = append(want, 0, 7)
+	want = append(want, "offline"...)
+	want = append(want, 0, 1, 'u', 0, 1, 'p')
+	if !bytes.Equal(got, want) {
+		t.Errorf("CONNECT body\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestMQTTConnectMinimal(t *testing.T) {
+	_, _, got, err := connectClient(t, mqttOptions{ClientID: "c1", KeepAlive: 10 * time.Second}, 0)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	want := []byte{0, 4, 'M', 'Q', 'T', 'T', 4, 0x02, 0, 10, 0, 2, 'c', '1'}
+	if !bytes.Equal(got, want) {
+		t.Errorf("CONNECT body\n got %q\nwant %q", got, want)
+	}
+}
+
+func TestMQTTConnAckRefused(t *testing.T) {
+	tests := []struct {
+		code byte
+		want string
+	}{
+		{1, "unacceptable protocol version"},
+		{4, "bad user name or password"},
+		{5, "not authorized"},
+		{9, "code 9"},
+	}
+	for _, tt := range tests {
+		_, _, _, err := connectClient(t, mqttOptions{ClientID: "c1", KeepAlive: 30 * time.Second}, tt.code)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("CONNACK %d: err = %v, want %q", tt.code, err, tt.want)
+		}
+	}
+}
+
+type mqttMessage struct {
+	topic, payload string
+	retained       bool
+}
+
+func TestMQTTSubscribeAndServe(t *testing.T) {
+	c, srv, _, err := connectClient(t, mqttOptions{ClientID: "c1", KeepAlive: 30 * time.Second}, 0)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	subErr := make(chan error, 1)
+	go func() { subErr <- c.Subscribe("a/+", "b/#") }()
+
+	body := expectPacket(t, srv, mqttSubscribe)
+	id := body[:2]
+	want := append(append([]byte{}, id...), 0, 3, 'a', '/', '+', 0, 0, 3, 'b', '/', '#', 0)
+	if !bytes.Equal(body, want) {
+		t.Errorf("SUBSCRIBE body\n got %q\nwant %q", body, want)
+	}
+
+	// A retained message sent ahead of the SUBACK must not be lost.
+	if err := srv.write(mqttPublish|0x01, publishBody("a/x", "early")); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.write(mqttSubAck, append(append([]byte{}, id...), 0, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-subErr; err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	msgs := make(chan mqttMessage, 8)
+	served := make(chan error, 1)
+	go func() {
+		served <- c.Serve(func(topic string, payload []byte, retained bool) {
+			msgs <- mqttMessage{topic, string(payload), retained}
+		})
+	}()
+	recv := func() mqttMessage {
+		t.Helper()
+		select {
+		case m := <-msgs:
+			return m
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a message")
+			return mqttMessage{}
+		}
+	}
+	if m := recv(); m != (mqttMessage{"a/x", "early", true}) {
+		t.Errorf("queued message = %+v", m)
+	}
+
+	if err := srv.write(mqttPublish, publishBody("b/y/z", "live")); err != nil {
+		t.Fatal(err)
+	}
+	if m := recv(); m != (mqttMessage{"b/y/z", "live", false}) {
+		t.Errorf("live message = %+v", m)
+	}
+
+	// QoS 1 deliveries are acknowledged with their packet id.
+	qos1 := append(appendMQTTString(nil, "a/q"), 0, 7)
+	qos1 = append(qos1, "once"...)
+	if err := srv.write(mqttPublish|0x02, qos1); err != nil {
+		t.Fatal(err)
+	}
+	if ack := expectPacket(t, srv, mqttPubAck); !bytes.Equal(ack, []byte{0, 7}) {
+		t.Errorf("PUBACK body = %v, want packet id 7", ack)
+	}
+	if m := recv(); m != (mqttMessage{"a/q", "once", false}) {
+		t.Errorf("QoS 1 message = %+v", m)
+	}
+
+	c.Close()
+	expectPacket(t, srv, mqttDisconnect)
+	if err := <-served; err == nil {
+		t.Error("Serve returned nil after Close")
+	}
+}
+
+func TestMQTTSubscribeRefused(t *testing.T) {
+	c, srv, _, err := connectClient(t, mqttOptions{ClientID: "c1", KeepAlive: 30 * time.Second}, 0)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	subErr := make(chan error, 1)
+	go func() { subErr <- c.Subscribe("ok/#", "secret/#") }()
+	body := expectPacket(t, srv, mqttSubscribe)
+	if err := srv.write(mqttSubAck, []byte{body[0], body[1], 0, 0x80}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-subErr; err == nil || !strings.Contains(err.Error(), "secret/#") {
+		t.Errorf("Subscribe err = %v, want refusal of secret/#", err)
+	}
+}
+
+func TestMQTTPublish(t *testing.T) {
+	c, srv, _, err := connectClient(t, mqttOptions{ClientID: "c1", KeepAlive: 30 * time.Second}, 0)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	tests := []struct {
+		topic, payload string
+		retain         bool
+		header         byte
+	}{
+		{"x/status", "running", true, mqttPublish | 0x01},
+		{"x/event", "hello", false, mqttPublish},
+		{"x/empty", "", true, mqttPublish | 0x01},
+	}
+	for _, tt := range tests {
+		if err := c.Publish(tt.topic, []byte(tt.payload), tt.retain); err != nil {
+			t.Fatal(err)
+		}
+		body := expectPacket(t, srv, tt.header)
+		if topic, payload := splitPublish(t, body); topic != tt.topic || payload != tt.payload {
+			t.Errorf("PUBLISH = %q %q, want %q %q", topic, payload, tt.topic, tt.payload)
+		}
+	}
+}
+
+// recordActions returns mqttActions that log each call to the channel.
+func recordActions(st restStatus) (mqttActions, <-chan string) {
+	calls := make(chan string, 16)
+	return mqttActions{
+		Status: func() restStatus { return st },
+		Start:  func() error { calls <- "start"; return nil },
+		Stop:   func() error { calls <- "stop"; return nil },
+		PauseGPU: func(key gpuKey, pause bool) {
+			calls <- fmt.Sprintf("pause %s %d %v", key.Instance, key.Index, pause)
+		},
+	}, calls
+}
+
+func TestMQTTBridgeHandle(t *testing.T) {
+	act, calls := recordActions(restStatus{})
+	b := newMQTTBridge(MQTTConfig{}, "Rig 1", act, func(string, ...any) {})
+	named := mqttGPUID(restDevice{Instance: "my-inst", Index: 2})
+
+	tests := []struct {
+		topic, payload string
+		want           string
+	}{
+		{"olivetum/rig_1/command/start", "", "start"},
+		{"olivetum/rig_1/command/stop", "anything", "stop"},
+		{"olivetum/rig_1/command/restart", "", ""},
+		{"olivetum/rig_1/gpu/cuda-0/pause/set", "ON", "pause cuda 0 true"},
+		{"olivetum/rig_1/gpu/opencl-1/pause/set", " off ", "pause opencl 1 false"},
+		{"olivetum/rig_1/gpu/" + named + "/pause/set", "ON", "pause my-inst 2 true"},
+		{"olivetum/rig_1/gpu/cuda/pause/set", "ON", ""},
+		{"olivetum/rig_1/gpu/-1/pause/set", "ON", ""},
+		{"olivetum/rig_1/gpu/cuda-x/pause/set", "ON", ""},
+		{"olivetum/rig_1/gpu/cuda-0/pause/set", "maybe", ""},
+		{"olivetum/other/command/start", "", ""},
+	}
+	for _, tt := range tests {
+		b.handle(tt.topic, tt.payload)
+		got := ""
+		select {
+		case got = <-calls:
+		default:
+		}
+		if got != tt.want {
+			t.Errorf("handle(%q, %q) called %q, want %q", tt.topic, tt.payload, got, tt.want)
+		}
+	}
+}
+
+func TestMQTTBridgeSession(t *testing.T) {
+	addr, accept := fakeBroker(t)
+	act, calls := recordActions(restStatus{
+		State:    "running",
+		Hashrate: 30e6,
+		Devices:  []restDevice{{Instance: backendCUDA, Index: 0, Hashrate: 30e6, Temp: 60, Fan: 40}},
+	})
+	b := newMQTTBridge(MQTTConfig{Broker: addr, Discovery: true}, "rig1", act, func(string, ...any) {})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ran := make(chan struct{})
+	go func() {
+		defer close(ran)
+		b.Run(ctx)
+	}()
+
+	srv := accept()
+	connect := expectPacket(t, srv, mqttConnect)
+	if !bytes.Contains(connect, append(appendMQTTString(nil, "olivetum/rig1/availability"), 0, 7, 'o', 'f', 'f', 'l', 'i', 'n', 'e')) {
+		t.Errorf("CONNECT has no offline will: %q", connect)
+	}
+	if connect[7]&0x24 != 0x24 {
+		t.Errorf("CONNECT flags %#x, want a retained will", connect[7])
+	}
+	if err := srv.write(mqttConnAck, []byte{0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	sub := expectPacket(t, srv, mqttSubscribe)
+	for _, f := range []string{"olivetum/rig1/command/+", "olivetum/rig1/gpu/+/pause/set"} {
+		if !bytes.Contains(sub, appendMQTTString(nil, f)) {
+			t.Errorf("SUBSCRIBE %q lacks %q", sub, f)
+		}
+	}
+	// A stale retained command is ignored.
+	if err := srv.write(mqttPublish|0x01, publishBody("olivetum/rig1/command/stop", "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.write(mqttSubAck, []byte{sub[0], sub[1], 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Collect what the bridge publishes while the test sends commands.
+	var mu sync.Mutex
+	published := map[string]string{}
+	retained := map[string]bool{}
+	disconnected := make(chan struct{})
+	go func() {
+		for {
+			typ, body, err := srv.read()
+			if err != nil {
+				return
+			}
+			switch typ & 0xf0 {
+			case mqttPublish:
+				n := int(binary.BigEndian.Uint16(body))
+				mu.Lock()
+				published[string(body[2:2+n])] = string(body[2+n:])
+				retained[string(body[2:2+n])] = typ&0x01 != 0
+				mu.Unlock()
+			case mqttDisconnect:
+				close(disconnected)
+				return
+			}
+		}
+	}()
+
+	for _, cmd := range [][2]string{
+		{"olivetum/rig1/command/start", ""},
+		{"olivetum/rig1/gpu/cuda-0/pause/set", "ON"},
+		{"olivetum/rig1/command/stop", ""},
+	} {
+		if err := srv.write(mqttPublish, publishBody(cmd[0], cmd[1])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, want := range []string{"start", "pause cuda 0 true", "stop"} {
+		select {
+		case got := <-calls:
+			if got != want {
+				t.Errorf("action %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %q", want)
+		}
+	}
+
+	cancel()
+	select {
+	case <-disconnected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("bridge did not disconnect")
+	}
+	<-ran
+
+	mu.Lock()
+	defer mu.Unlock()
+	wantValues := map[string]string{
+		"olivetum/rig1/availability":                        "offline",
+		"olivetum/rig1/status":                              "running",
+		"olivetum/rig1/hashrate":                            "30.00",
+		"olivetum/rig1/gpu/cuda-0/temperature":              "60",
+		"olivetum/rig1/gpu/cuda-0/paused":                   "OFF",
+		"homeassistant/button/rig1/start/config":            "",
+		"homeassistant/switch/rig1/gpu_cuda_0_pause/config": "",
+	}
+	for topic, want := range wantValues {
+		got, ok := published[topic]
+		if !ok {
+			t.Errorf("nothing published on %s", topic)
+			continue
+		}
+		if want != "" && got != want {
+			t.Errorf("%s = %q, want %q", topic, got, want)
+		}
+		if !retained[topic] {
+			t.Errorf("%s was not retained", topic)
+		}
+	}
+	select {
+	case got := <-calls:
+		t.Errorf("unexpected action %q (retained command replayed?)", got)
+	default:
+	}
+}
+
+func TestMQTTWriteToStalledBroker(t *testing.T) {
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	c := &mqttClient{conn: conn, r: bufio.NewReader(conn), keepAlive: mqttKeepAlive}
+
+	// Nobody reads from peer, so the write can only end by its deadline.
+	done := make(chan error, 1)
+	go func() { done <- c.writeWithin(50*time.Millisecond, mqttPublish, publishBody("t", "v")) }()
+	select {
+	case err := <-done:
+		var ne net.Error
+		if !errors.As(err, &ne) || !ne.Timeout() {
+			t.Errorf("write = %v, want a timeout", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("write to a stalled broker did not time out")
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on a stalled broker")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "fyne.io/fyne/v2"
+
+// NotificationsConfig selects the alerts shown as desktop notifications.
+type NotificationsConfig struct {
+	Crash       bool `json:"crash"`
+	Restart     bool `json:"restart"`
+	Rejects     bool `json:"rejects"`
+	Temperature bool `json:"temperature"`
+	BlockFound  bool `json:"blockFound"`
+}
+
+func defaultNotifications() NotificationsConfig {
+	return NotificationsConfig{Crash: true, Restart: true, Rejects: true, Temperature: true, BlockFound: true}
+}
+
+func (n NotificationsConfig) enabled(kind string) bool {
+	switch kind {
+	case alertCrash:
+		return n.Crash
+	case alertRestart:
+		return n.Restart
+	case alertRejectSpike:
+		return n.Rejects
+	case alertTemperature:
+		return n.Temperature
+	case alertBlockFound:
+		return n.BlockFound
+	default:
+		return false
+	}
+}
+
+// desktopNotification renders ev for App.SendNotification.
+func desktopNotification(ev alertEvent) *fyne.Notification {
+	title := "Olivetum Miner"
+	switch ev.Kind {
+	case alertCrash:
+		title = "Miner crashed"
+	case alertRestart:
+		title = "Miner restarted"
+	case alertRejectSpike:
+		title = "Rejected shares"
+	case alertTemperature:
+		title = "GPU temperature"
+	case alertBlockFound:
+		title = "Block found"
+	}
+	return fyne.NewNotification(title, ev.Message)
+}
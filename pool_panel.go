@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// poolHost shortens a pool URI to host:port so wallet addresses don't fill
+// the dashboard.
+func poolHost(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Host == "" {
+		return uri
+	}
+	return u.Host
+}
+
+// parseFailoverPools parses "host:port, host:port" into a list of stratum
+// endpoints.
+func parseFailoverPools(text string) ([]string, error) {
+	var res []string
+	for _, part := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' || r == ' ' }) {
+		host, portText, err := net.SplitHostPort(part)
+		if err != nil || host == "" {
+			return nil, fmt.Errorf("invalid failover pool %q (expected host:port)", part)
+		}
+		port, err := strconv.Atoi(portText)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid failover pool port in %q", part)
+		}
+		res = append(res, part)
+	}
+	return res, nil
+}
+
+// poolTracker turns successive miner_getconnections results into switch
+// events. A change to the connection last requested through the GUI counts
+// as manual; anything else is ethminer failing over on its own.
+type poolTracker struct {
+	mu        sync.Mutex
+	active    int
+	requested int
+}
+
+func newPoolTracker() *poolTracker {
+	return &poolTracker{active: -1, requested: -1}
+}
+
+func (t *poolTracker) Requested(index int) {
+	t.mu.Lock()
+	t.requested = index
+	t.mu.Unlock()
+}
+
+// Observe records the active connection and reports whether it changed
+// since the previous call.
+func (t *poolTracker) Observe(conns []poolConnection) (from, to int, manual, changed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cur := -1
+	for _, c := range conns {
+		if c.Active {
+			cur = c.Index
+		}
+	}
+	if cur < 0 {
+		return 0, 0, false, false
+	}
+	prev := t.active
+	t.active = cur
+	if prev < 0 || prev == cur {
+		return prev, cur, false, false
+	}
+	manual = t.requested == cur
+	t.requested = -1
+	return prev, cur, manual, true
+}
+
+func (t *poolTracker) Reset() {
+	t.mu.Lock()
+	t.active, t.requested = -1, -1
+	t.mu.Unlock()
+}
+
+// poolList shows the miner's pool connections with a "Switch to" action on
+// the inactive ones. All methods must be called on the UI goroutine.
+type poolList struct {
+	box      *fyne.Container
+	view     fyne.CanvasObject
+	onSwitch func(c poolConnection)
+	last     []poolConnection
+}
+
+func newPoolList(onSwitch func(c poolConnection)) *poolList {
+	p := &poolList{box: container.NewVBox(), onSwitch: onSwitch}
+	title := widget.NewLabelWithStyle("Pools", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+	title.Wrapping = fyne.TextWrapOff
+	p.view = metricTileWithHeader(container.NewHBox(widget.NewIcon(theme.StorageIcon()), title), p.box)
+	p.view.Hide()
+	return p
+}
+
+func (p *poolList) Object() fyne.CanvasObject {
+	return p.view
+}
+
+func (p *poolList) Update(conns []poolConnection) {
+	if samePoolConnections(conns, p.last) {
+		return
+	}
+	p.last = append([]poolConnection(nil), conns...)
+	objs := make([]fyne.CanvasObject, 0, len(conns))
+	for _, c := range conns {
+		label := widget.NewLabelWithStyle(fmt.Sprintf("#%d  %s", c.Index, poolHost(c.URI)), fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		label.Truncation = fyne.TextTruncateEllipsis
+		var right fyne.CanvasObject
+		if c.Active {
+			active := widget.NewLabelWithStyle("ACTIVE", fyne.TextAlignCenter, fyne.TextStyle{Bold: true})
+			active.Importance = widget.SuccessImportance
+			right = active
+		} else {
+			btn := widget.NewButtonWithIcon("Switch to", theme.MediaSkipNextIcon(), func() { p.onSwitch(c) })
+			btn.Importance = widget.LowImportance
+			right = btn
+		}
+		objs = append(objs, container.NewBorder(nil, nil, widget.NewIcon(theme.StorageIcon()), right, label))
+	}
+	p.box.Objects = objs
+	p.box.Refresh()
+	p.view.Show()
+}
+
+func (p *poolList) Reset() {
+	p.last = nil
+	p.box.Objects = nil
+	p.box.Refresh()
+	p.view.Hide()
+}
+
+func samePoolConnections(a, b []poolConnection) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func connectionURI(conns []poolConnection, index int) string {
+	for _, c := range conns {
+		if c.Index == index {
+			return c.URI
+		}
+	}
+	return ""
+}
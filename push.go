@@ -0,0 +1,443 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPushInterval = 10
+	defaultStatsDPrefix = "olivetum"
+
+	// influxMaxBuffered is how many samples are held while InfluxDB is
+	// unreachable: 8 hours at the default interval.
+	influxMaxBuffered = 2880
+	influxBatchLines  = 5000
+	// statsdMaxPacket keeps datagrams under a typical MTU.
+	statsdMaxPacket = 1432
+	pushTimeout     = 10 * time.Second
+)
+
+// PushConfig controls the push exporters. Each sends the polled stats every
+// IntervalSec seconds.
+type PushConfig struct {
+	IntervalSec int          `json:"intervalSec"`
+	Influx      InfluxConfig `json:"influx"`
+	StatsD      StatsDConfig `json:"statsd"`
+}
+
+// InfluxConfig posts line protocol to an InfluxDB write endpoint, e.g.
+// http://host:8086/api/v2/write?org=o&bucket=b or http://host:8086/write?db=d.
+type InfluxConfig struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url,omitempty"`
+	Token   string `json:"token,omitempty"`
+}
+
+// StatsDConfig sends gauges to a StatsD server over UDP. Without Tags the
+// rig, worker, instance and GPU are encoded in the metric name.
+type StatsDConfig struct {
+	Enabled bool   `json:"enabled"`
+	Addr    string `json:"addr,omitempty"`
+	Prefix  string `json:"prefix,omitempty"`
+	Tags    bool   `json:"tags,omitempty"`
+}
+
+func (p PushConfig) interval() time.Duration {
+	if p.IntervalSec <= 0 {
+		return defaultPushInterval * time.Second
+	}
+	return time.Duration(p.IntervalSec) * time.Second
+}
+
+func (p PushConfig) Validate() error {
+	if p.IntervalSec < 0 || p.IntervalSec > 3600 {
+		return fmt.Errorf("invalid push interval (1..3600 s)")
+	}
+	if p.Influx.Enabled {
+		u, err := url.Parse(p.Influx.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid InfluxDB write URL %q", p.Influx.URL)
+		}
+	}
+	if p.StatsD.Enabled {
+		host, port, err := net.SplitHostPort(p.StatsD.Addr)
+		if n, perr := strconv.Atoi(port); err != nil || host == "" || perr != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("invalid StatsD address %q (host:port)", p.StatsD.Addr)
+		}
+	}
+	return nil
+}
+
+// pushSample is one round of stats for the exporters.
+type pushSample struct {
+	Time      time.Time
+	Rig       string
+	Worker    string
+	Instances []instanceSnapshot
+}
+
+// pushSink is one push target. Encode turns a sample into lines, Send
+// delivers a batch of lines, and MaxBuffered bounds the samples kept while
+// the target is unreachable.
+type pushSink interface {
+	Name() string
+	Encode(s pushSample) []string
+	Send(ctx context.Context, lines []string) error
+	MaxBuffered() int
+}
+
+// errPushRejected marks data the target refused; it is dropped instead of
+// retried.
+var errPushRejected = errors.New("rejected by target")
+
+func pushSinks(p PushConfig) []pushSink {
+	var sinks []pushSink
+	if p.Influx.Enabled {
+		sinks = append(sinks, &influxSink{url: p.Influx.URL, token: p.Influx.Token, client: &http.Client{Timeout: pushTimeout}})
+	}
+	if p.StatsD.Enabled {
+		prefix := p.StatsD.Prefix
+		if prefix == "" {
+			prefix = defaultStatsDPrefix
+		}
+		sinks = append(sinks, &statsdSink{addr: p.StatsD.Addr, prefix: prefix, tags: p.StatsD.Tags})
+	}
+	return sinks
+}
+
+// gpuValues is the per-GPU data of one instance, from the detailed stat if
+// ethminer provided it.
+type gpuValues struct {
+	Index   int
+	Name    string
+	KHs     int64
+	Temp    int
+	Fan     int
+	HasTemp bool
+	HasFan  bool
+}
+
+func gpusOf(st Stat) []gpuValues {
+	var res []gpuValues
+	if len(st.Devices) > 0 {
+		for _, d := range st.Devices {
+			res = append(res, gpuValues{Index: d.Index, Name: d.Name, KHs: d.KHs, Temp: d.Temp, Fan: d.Fan, HasTemp: true, HasFan: true})
+		}
+		return res
+	}
+	for i, kh := range st.PerGPU_KHs {
+		g := gpuValues{Index: i, KHs: kh}
+		if i < len(st.Temps) {
+			g.Temp, g.HasTemp = st.Temps[i], true
+		}
+		if i < len(st.Fans) {
+			g.Fan, g.HasFan = st.Fans[i], true
+		}
+		res = append(res, g)
+	}
+	return res
+}
+
+// influxSink writes InfluxDB line protocol over HTTP.
+type influxSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+func (s *influxSink) Name() string     { return "influx" }
+func (s *influxSink) MaxBuffered() int { return influxMaxBuffered }
+
+var influxTagEscaper = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `, "\n", `\n`)
+
+// influxTags renders ",k=v" pairs, skipping empty values as line protocol
+// requires.
+func influxTags(kv ...string) string {
+	var b strings.Builder
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] == "" {
+			continue
+		}
+		fmt.Fprintf(&b, ",%s=%s", kv[i], influxTagEscaper.Replace(kv[i+1]))
+	}
+	return b.String()
+}
+
+func (s *influxSink) Encode(p pushSample) []string {
+	ts := strconv.FormatInt(p.Time.UnixNano(), 10)
+	var lines []string
+	for _, sn := range p.Instances {
+		if !sn.HasStat {
+			continue
+		}
+		st := sn.Stat
+		tags := influxTags("rig", p.Rig, "worker", p.Worker, "instance", sn.Name)
+		lines = append(lines, fmt.Sprintf("olivetum_miner%s hashrate=%d,accepted=%di,rejected=%di,invalid=%di,pool_switches=%di,uptime=%di,restarts=%di %s",
+			tags, st.TotalKHs*1000, st.Accepted, st.Rejected, st.Invalid, st.PoolSwitches, st.UptimeMin*60, sn.Restarts, ts))
+		for _, g := range gpusOf(st) {
+			fields := fmt.Sprintf("hashrate=%d", g.KHs*1000)
+			if g.HasTemp {
+				fields += fmt.Sprintf(",temperature=%di", g.Temp)
+			}
+			if g.HasFan {
+				fields += fmt.Sprintf(",fan=%di", g.Fan)
+			}
+			gtags := influxTags("rig", p.Rig, "worker", p.Worker, "instance", sn.Name, "gpu", strconv.Itoa(g.Index), "name", g.Name)
+			lines = append(lines, fmt.Sprintf("olivetum_gpu%s %s %s", gtags, fields, ts))
+		}
+	}
+	return lines
+}
+
+func (s *influxSink) Send(ctx context.Context, lines []string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, strings.NewReader(strings.Join(lines, "\n")+"\n"))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Token "+s.token)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		// InfluxDB 1.x URLs may carry u= and p= credentials.
+		return unwrapURLError(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	err = fmt.Errorf("HTTP %s", resp.Status)
+	if msg := strings.TrimSpace(string(body)); msg != "" {
+		err = fmt.Errorf("HTTP %s: %s", resp.Status, msg)
+	}
+	// Malformed or oversized writes fail the same way every time.
+	if resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusRequestEntityTooLarge || resp.StatusCode == http.StatusUnprocessableEntity {
+		return fmt.Errorf("%w: %v", errPushRejected, err)
+	}
+	return err
+}
+
+// statsdSink sends gauges over UDP. StatsD has no timestamps, so only the
+// newest sample is kept for retry.
+type statsdSink struct {
+	addr   string
+	prefix string
+	tags   bool
+}
+
+func (s *statsdSink) Name() string     { return "statsd" }
+func (s *statsdSink) MaxBuffered() int { return 1 }
+
+// statsdSegment makes v safe as one dot-separated metric name segment.
+func statsdSegment(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, v)
+}
+
+func (s *statsdSink) gauge(name string, value int64, kv ...string) string {
+	if s.tags {
+		var tags []string
+		for i := 0; i+1 < len(kv); i += 2 {
+			if kv[i+1] != "" {
+				tags = append(tags, kv[i]+":"+strings.NewReplacer(",", "_", "|", "_", " ", "_").Replace(kv[i+1]))
+			}
+		}
+		line := fmt.Sprintf("%s.%s:%d|g", s.prefix, name, value)
+		if len(tags) > 0 {
+			line += "|#" + strings.Join(tags, ",")
+		}
+		return line
+	}
+	path := []string{s.prefix}
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] == "" {
+			continue
+		}
+		seg := statsdSegment(kv[i+1])
+		if kv[i] == "gpu" {
+			// "….cuda.gpu0.hashrate" rather than "….gpu0.gpu.hashrate".
+			seg = "gpu" + seg
+			name = strings.TrimPrefix(name, "gpu.")
+		}
+		path = append(path, seg)
+	}
+	return fmt.Sprintf("%s.%s:%d|g", strings.Join(path, "."), name, value)
+}
+
+func (s *statsdSink) Encode(p pushSample) []string {
+	var lines []string
+	for _, sn := range p.Instances {
+		if !sn.HasStat {
+			continue
+		}
+		st := sn.Stat
+		tags := []string{"rig", p.Rig, "worker", p.Worker, "instance", sn.Name}
+		lines = append(lines,
+			s.gauge("hashrate", st.TotalKHs*1000, tags...),
+			s.gauge("shares.accepted", st.Accepted, tags...),
+			s.gauge("shares.rejected", st.Rejected, tags...),
+			s.gauge("shares.invalid", st.Invalid, tags...),
+			s.gauge("pool_switches", st.PoolSwitches, tags...),
+			s.gauge("uptime", int64(st.UptimeMin*60), tags...),
+			s.gauge("restarts", int64(sn.Restarts), tags...),
+		)
+		for _, g := range gpusOf(st) {
+			gtags := append(append([]string(nil), tags...), "gpu", strconv.Itoa(g.Index))
+			lines = append(lines, s.gauge("gpu.hashrate", g.KHs*1000, gtags...))
+			if g.HasTemp {
+				lines = append(lines, s.gauge("gpu.temperature", int64(g.Temp), gtags...))
+			}
+			if g.HasFan {
+				lines = append(lines, s.gauge("gpu.fan", int64(g.Fan), gtags...))
+			}
+		}
+	}
+	return lines
+}
+
+func (s *statsdSink) Send(ctx context.Context, lines []string) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", s.addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > statsdMaxPacket {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	return flush()
+}
+
+// pushExporter buffers encoded samples for one sink and sends them in
+// order, keeping them while the target is unreachable.
+type pushExporter struct {
+	sink pushSink
+	logf func(format string, args ...any)
+
+	pending [][]string
+	dropped int
+	lastErr string
+}
+
+func newPushExporter(sink pushSink, logf func(format string, args ...any)) *pushExporter {
+	return &pushExporter{sink: sink, logf: logf}
+}
+
+// Add queues a sample, dropping the oldest once the buffer is full.
+func (e *pushExporter) Add(s pushSample) {
+	lines := e.sink.Encode(s)
+	if len(lines) == 0 {
+		return
+	}
+	e.pending = append(e.pending, lines)
+	if over := len(e.pending) - e.sink.MaxBuffered(); over > 0 {
+		e.pending = e.pending[over:]
+		e.dropped += over
+	}
+}
+
+// Flush sends the buffered samples oldest first and stops at the first
+// failure.
+func (e *pushExporter) Flush(ctx context.Context) {
+	for len(e.pending) > 0 {
+		var batch []string
+		n := 0
+		for n < len(e.pending) && (n == 0 || len(batch)+len(e.pending[n]) <= influxBatchLines) {
+			batch = append(batch, e.pending[n]...)
+			n++
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, pushTimeout)
+		err := e.sink.Send(sendCtx, batch)
+		cancel()
+		if err != nil && !errors.Is(err, errPushRejected) {
+			if msg := err.Error(); msg != e.lastErr {
+				e.lastErr = msg
+				e.logf("[push] %s: %v (buffering)\n", e.sink.Name(), err)
+			}
+			return
+		}
+		if err != nil {
+			e.logf("[push] %s: dropped %d sample(s): %v\n", e.sink.Name(), n, err)
+		}
+		e.pending = e.pending[n:]
+		if e.lastErr != "" && err == nil {
+			e.lastErr = ""
+			msg := fmt.Sprintf("[push] %s: delivering again", e.sink.Name())
+			if e.dropped > 0 {
+				msg += fmt.Sprintf(", %d sample(s) dropped while unreachable", e.dropped)
+			}
+			e.logf("%s\n", msg)
+			e.dropped = 0
+		}
+	}
+}
+
+// run collects a sample every interval and flushes it until ctx is done.
+// Each exporter has its own ticker, so a target that times out only delays
+// its own samples.
+func (e *pushExporter) run(ctx context.Context, interval time.Duration, collect func() (pushSample, bool)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if s, ok := collect(); ok {
+			e.Add(s)
+		}
+		e.Flush(ctx)
+	}
+}
+
+// runPush pushes to sinks every interval until ctx is done. collect reports
+// false while there is nothing to send; it is called from one goroutine per
+// sink.
+func runPush(ctx context.Context, interval time.Duration, sinks []pushSink, collect func() (pushSample, bool), logf func(format string, args ...any)) {
+	var wg sync.WaitGroup
+	for _, s := range sinks {
+		wg.Add(1)
+		go func(e *pushExporter) {
+			defer wg.Done()
+			e.run(ctx, interval, collect)
+		}(newPushExporter(s, logf))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testPushSample() pushSample {
+	st := Stat{
+		UptimeMin:    3,
+		TotalKHs:     60000,
+		Accepted:     5,
+		Rejected:     1,
+		PoolSwitches: 2,
+		PerGPU_KHs:   []int64{30000, 30000},
+		Temps:        []int{60, 61},
+		Fans:         []int{40},
+	}
+	return pushSample{
+		Time:   time.Unix(1700000000, 0),
+		Rig:    "rig 1",
+		Worker: "w,1",
+		Instances: []instanceSnapshot{
+			{Name: "cuda", Restarts: 1, Stat: st, HasStat: true},
+			{Name: "opencl"},
+		},
+	}
+}
+
+func TestInfluxSink(t *testing.T) {
+	type request struct {
+		auth, contentType, body string
+	}
+	reqs := make(chan request, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		reqs <- request{r.Header.Get("Authorization"), r.Header.Get("Content-Type"), string(b)}
+		if strings.Contains(string(b), "bad") {
+			http.Error(w, "unable to parse", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink := pushSinks(PushConfig{Influx: InfluxConfig{Enabled: true, URL: srv.URL + "/api/v2/write?bucket=b", Token: "tok"}})[0]
+	lines := sink.Encode(testPushSample())
+	want := []string{
+		`olivetum_miner,rig=rig\ 1,worker=w\,1,instance=cuda hashrate=60000000,accepted=5i,rejected=1i,invalid=0i,pool_switches=2i,uptime=180i,restarts=1i 1700000000000000000`,
+		`olivetum_gpu,rig=rig\ 1,worker=w\,1,instance=cuda,gpu=0 hashrate=30000000,temperature=60i,fan=40i 1700000000000000000`,
+		`olivetum_gpu,rig=rig\ 1,worker=w\,1,instance=cuda,gpu=1 hashrate=30000000,temperature=61i 1700000000000000000`,
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines =\n%s\nwant\n%s", strings.Join(lines, "\n"), strings.Join(want, "\n"))
+	}
+
+	if err := sink.Send(context.Background(), lines); err != nil {
+		t.Fatal(err)
+	}
+	r := <-reqs
+	if r.auth != "Token tok" || !strings.HasPrefix(r.contentType, "text/plain") || r.body != strings.Join(want, "\n")+"\n" {
+		t.Errorf("request = %+v", r)
+	}
+
+	err := sink.Send(context.Background(), []string{"bad"})
+	if !errors.Is(err, errPushRejected) || !strings.Contains(err.Error(), "unable to parse") {
+		t.Errorf("Send(bad) = %v, want a rejection with the server message", err)
+	}
+}
+
+func TestStatsDSinkSplitsPackets(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+
+	sink := &statsdSink{addr: pc.LocalAddr().String(), prefix: "olivetum"}
+	if got, want := sink.Encode(testPushSample())[:2], []string{
+		"olivetum.rig_1.w_1.cuda.hashrate:60000000|g",
+		"olivetum.rig_1.w_1.cuda.shares.accepted:5|g",
+	}; !reflect.DeepEqual(got, want) {
+		t.Errorf("lines = %q, want %q", got, want)
+	}
+	tagged := &statsdSink{prefix: "olivetum", tags: true}
+	if got, want := tagged.gauge("gpu.hashrate", 1, "rig", "rig 1", "gpu", "0"), "olivetum.gpu.hashrate:1|g|#rig:rig_1,gpu:0"; got != want {
+		t.Errorf("tagged gauge = %q, want %q", got, want)
+	}
+
+	var lines []string
+	for i := 0; i < 100; i++ {
+		lines = append(lines, fmt.Sprintf("olivetum.rig.gpu%d.hashrate:%d|g", i, 30000000+i))
+	}
+	if err := sink.Send(context.Background(), lines); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	buf := make([]byte, 64<<10)
+	for len(got) < len(lines) {
+		pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := pc.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("after %d lines: %v", len(got), err)
+		}
+		if n > statsdMaxPacket {
+			t.Errorf("packet of %d bytes, max %d", n, statsdMaxPacket)
+		}
+		got = append(got, strings.Split(string(buf[:n]), "\n")...)
+	}
+	if !reflect.DeepEqual(got, lines) {
+		t.Errorf("received %d lines, want the %d sent in order", len(got), len(lines))
+	}
+}
+
+// fakeSink records batches and fails while down is set.
+type fakeSink struct {
+	max int
+
+	mu      sync.Mutex
+	down    bool
+	batches [][]string
+}
+
+func (s *fakeSink) Name() string     { return "fake" }
+func (s *fakeSink) MaxBuffered() int { return s.max }
+
+func (s *fakeSink) Encode(p pushSample) []string {
+	return []string{p.Time.Format(time.TimeOnly)}
+}
+
+func (s *fakeSink) Send(ctx context.Context, lines []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.down {
+		return errors.New("connection refused")
+	}
+	s.batches = append(s.batches, lines)
+	return nil
+}
+
+func TestPushExporterBuffersAndDrops(t *testing.T) {
+	sink := &fakeSink{max: 3, down: true}
+	var logs []string
+	e := newPushExporter(sink, func(format string, args ...any) { logs = append(logs, fmt.Sprintf(format, args...)) })
+
+	t0 := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		e.Add(pushSample{Time: t0.Add(time.Duration(i) * time.Second)})
+		e.Flush(context.Background())
+	}
+	if len(e.pending) != 3 || e.dropped != 2 {
+		t.Errorf("pending %d, dropped %d; want 3 and 2", len(e.pending), e.dropped)
+	}
+	// The same failure is logged once.
+	if len(logs) != 1 || !strings.Contains(logs[0], "connection refused (buffering)") {
+		t.Errorf("logs = %q", logs)
+	}
+
+	sink.down = false
+	e.Flush(context.Background())
+	if want := [][]string{{"12:00:02", "12:00:03", "12:00:04"}}; !reflect.DeepEqual(sink.batches, want) {
+		t.Errorf("batches = %q, want the newest 3 samples in one batch", sink.batches)
+	}
+	if len(e.pending) != 0 || e.dropped != 0 {
+		t.Errorf("pending %d, dropped %d after recovering", len(e.pending), e.dropped)
+	}
+	if n := len(logs); n != 2 || logs[1] != "[push] fake: delivering again, 2 sample(s) dropped while unreachable\n" {
+		t.Errorf("logs = %q", logs)
+	}
+}
+
+// stallSink blocks every send until its context ends.
+type stallSink struct{ fakeSink }
+
+func (s *stallSink) Send(ctx context.Context, lines []string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestRunPushTargetsAreIndependent(t *testing.T) {
+	stalled := &stallSink{fakeSink{max: 10}}
+	healthy := &fakeSink{max: 10}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		collect := func() (pushSample, bool) { return pushSample{Time: time.Now()}, true }
+		runPush(ctx, 10*time.Millisecond, []pushSink{stalled, healthy}, collect, func(string, ...any) {})
+	}()
+
+	// The stalled target holds each send for pushTimeout; the healthy one
+	// keeps delivering meanwhile.
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		healthy.mu.Lock()
+		n := len(healthy.batches)
+		healthy.mu.Unlock()
+		if n >= 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("healthy target got %d batches while the other one stalled", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runPush did not return after cancel")
+	}
+}
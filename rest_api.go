@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultRESTPort = 9479
+
+// errMinerRunning and errMinerStopped are reported by start and stop when
+// the miner is already in the requested state.
+var (
+	errMinerRunning = errors.New("miner already running")
+	errMinerStopped = errors.New("miner is not running")
+)
+
+// RESTConfig controls the local HTTP control API. It only ever listens on
+// the loopback interface.
+type RESTConfig struct {
+	Enabled bool   `json:"enabled"`
+	Port    int    `json:"port,omitempty"`
+	Token   string `json:"token,omitempty"`
+}
+
+func (r RESTConfig) addr() string {
+	return net.JoinHostPort("127.0.0.1", strconv.Itoa(r.Port))
+}
+
+// remoteSettings are the settings GET/PUT /config exchange: the fields of
+// the main form, with the same JSON names as Config. Secrets, binaries and
+// device selection are left out.
+type remoteSettings struct {
+	Mode            string   `json:"mode"`
+	Backend         string   `json:"backend"`
+	StratumHost     string   `json:"stratumHost"`
+	StratumPort     int      `json:"stratumPort"`
+	RPCURL          string   `json:"rpcUrl"`
+	WalletAddress   string   `json:"walletAddress"`
+	WorkerName      string   `json:"workerName"`
+	ReportHashrate  bool     `json:"reportHashrate"`
+	DisplayInterval int      `json:"displayInterval"`
+	PollInterval    int      `json:"pollInterval"`
+	RestartPolicy   string   `json:"restartPolicy"`
+	FailoverPools   []string `json:"failoverPools"`
+	StartOnLaunch   bool     `json:"startOnLaunch"`
+	StartupDelay    int      `json:"startupDelay"`
+}
+
+func settingsOf(cfg *Config) remoteSettings {
+	return remoteSettings{
+		Mode:            cfg.Mode,
+		Backend:         cfg.Backend,
+		StratumHost:     cfg.StratumHost,
+		StratumPort:     cfg.StratumPort,
+		RPCURL:          cfg.RPCURL,
+		WalletAddress:   cfg.WalletAddress,
+		WorkerName:      cfg.WorkerName,
+		ReportHashrate:  cfg.ReportHashrate,
+		DisplayInterval: cfg.DisplayInterval,
+		PollInterval:    cfg.PollInterval,
+		RestartPolicy:   cfg.RestartPolicy,
+		FailoverPools:   append([]string{}, cfg.FailoverPools...),
+		StartOnLaunch:   cfg.StartOnLaunch,
+		StartupDelay:    cfg.StartupDelay,
+	}
+}
+
+type restDevice struct {
+	Instance string `json:"instance"`
+	Index    int    `json:"index"`
+	Name     string `json:"name"`
+	PCI      string `json:"pci,omitempty"`
+	Hashrate int64  `json:"hashrate"`
+	Temp     int    `json:"temp"`
+	Fan      int    `json:"fan"`
+	Paused   bool   `json:"paused"`
+}
+
+type restStatus struct {
+	State     string       `json:"state"`
+	Hashrate  int64        `json:"hashrate"`
+	Accepted  int64        `json:"accepted"`
+	Rejected  int64        `json:"rejected"`
+	Invalid   int64        `json:"invalid"`
+	Pool      string       `json:"pool,omitempty"`
+	UptimeMin int          `json:"uptimeMin"`
+	Devices   []restDevice `json:"devices"`
+}
+
+// newRESTStatus summarizes a metrics snapshot; hashrates are in H/s.
+func newRESTStatus(snap metricsSnapshot, rows []gpuRowData) restStatus {
+	st := restStatus{State: snap.State, Devices: []restDevice{}}
+	var stats []Stat
+	for _, sn := range snap.Instances {
+		if sn.HasStat {
+			stats = append(stats, sn.Stat)
+		}
+	}
+	if len(stats) > 0 {
+		s := aggregateStats(stats)
+		st.Hashrate = s.TotalKHs * 1000
+		st.Accepted, st.Rejected, st.Invalid = s.Accepted, s.Rejected, s.Invalid
+		st.Pool = s.Pool
+		st.UptimeMin = s.UptimeMin
+	}
+	for _, r := range rows {
+		st.Devices = append(st.Devices, restDevice{
+			Instance: r.Key.Instance,
+			Index:    r.Key.Index,
+			Name:     r.Name,
+			PCI:      r.PCI,
+			Hashrate: r.KHs * 1000,
+			Temp:     r.Temp,
+			Fan:      r.Fan,
+			Paused:   r.Paused,
+		})
+	}
+	return st
+}
+
+// restActions are the GUI code paths the API drives. Start, Stop, Restart
+// and PutConfig are called from the HTTP goroutine and must hop to the UI
+// goroutine themselves.
+type restActions struct {
+	Status    func() restStatus
+	Start     func() error
+	Stop      func() error
+	Restart   func() error
+	GetConfig func() remoteSettings
+	PutConfig func(remoteSettings) error
+}
+
+// serveREST serves the control API on ln until the returned server is
+// closed. Every request needs "Authorization: Bearer <token>".
+func serveREST(ln net.Listener, token string, act restActions) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, act.Status())
+	})
+	for path, fn := range map[string]func() error{
+		"/start":   act.Start,
+		"/stop":    act.Stop,
+		"/restart": act.Restart,
+	} {
+		fn := fn
+		mux.HandleFunc("POST "+path, func(w http.ResponseWriter, r *http.Request) {
+			if err := fn(); err != nil {
+				writeRESTError(w, err)
+				return
+			}
+			writeJSON(w, http.StatusAccepted, act.Status())
+		})
+	}
+	mux.HandleFunc("GET /config", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, act.GetConfig())
+	})
+	mux.HandleFunc("PUT /config", func(w http.ResponseWriter, r *http.Request) {
+		// Fields left out keep their current value.
+		s := act.GetConfig()
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 64<<10))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&s); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := act.PutConfig(s); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, act.GetConfig())
+	})
+
+	auth := func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="olivetum-miner"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing token"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(auth), ReadHeaderTimeout: 5 * time.Second}
+	go func() { _ = srv.Serve(ln) }()
+	return srv
+}
+
+func writeRESTError(w http.ResponseWriter, err error) {
+	code := http.StatusBadRequest
+	if errors.Is(err, errMinerRunning) || errors.Is(err, errMinerStopped) {
+		code = http.StatusConflict
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
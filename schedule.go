@@ -0,0 +1,313 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minutesPerDay  = 24 * 60
+	minutesPerWeek = 7 * minutesPerDay
+)
+
+// ScheduleWindow is one mining window on a weekday. End may be before Start,
+// in which case the window runs past midnight into the next day.
+type ScheduleWindow struct {
+	Day   time.Weekday `json:"day"`
+	Start string       `json:"start"`
+	End   string       `json:"end"`
+}
+
+type ScheduleConfig struct {
+	Enabled bool             `json:"enabled"`
+	Windows []ScheduleWindow `json:"windows,omitempty"`
+}
+
+// parseClock parses "HH:MM" into minutes since midnight. "24:00" is accepted
+// so a window can end exactly at midnight.
+func parseClock(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	hh, mm, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	h, err1 := strconv.Atoi(hh)
+	m, err2 := strconv.Atoi(mm)
+	if err1 != nil || err2 != nil || h < 0 || h > 24 || m < 0 || m > 59 || (h == 24 && m != 0) {
+		return 0, fmt.Errorf("invalid time %q (expected HH:MM)", s)
+	}
+	return h*60 + m, nil
+}
+
+func formatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// span returns the window as [start, end) in minutes since Sunday 00:00.
+// The end can exceed minutesPerWeek for windows wrapping into the next week.
+func (w ScheduleWindow) span() (int, int, error) {
+	if w.Day < time.Sunday || w.Day > time.Saturday {
+		return 0, 0, fmt.Errorf("invalid weekday %d", w.Day)
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return 0, 0, err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return 0, 0, err
+	}
+	if start == end {
+		return 0, 0, fmt.Errorf("empty window %s-%s", w.Start, w.End)
+	}
+	if start == minutesPerDay {
+		return 0, 0, fmt.Errorf("window cannot start at %s", w.Start)
+	}
+	if end < start {
+		end += minutesPerDay
+	}
+	base := int(w.Day) * minutesPerDay
+	return base + start, base + end, nil
+}
+
+func (s ScheduleConfig) Validate() error {
+	if s.Enabled && len(s.Windows) == 0 {
+		return errors.New("schedule is enabled but has no windows")
+	}
+	for _, w := range s.Windows {
+		if _, _, err := w.span(); err != nil {
+			return fmt.Errorf("%s: %w", w.Day, err)
+		}
+	}
+	return nil
+}
+
+func minuteOfWeek(t time.Time) int {
+	return int(t.Weekday())*minutesPerDay + t.Hour()*60 + t.Minute()
+}
+
+// weekRanges returns the valid windows as sorted, non-overlapping
+// [start, end) minutes of the week. Windows running past Saturday midnight
+// are split at the end of the week.
+func (s ScheduleConfig) weekRanges() [][2]int {
+	var ranges [][2]int
+	for _, w := range s.Windows {
+		start, end, err := w.span()
+		if err != nil {
+			continue
+		}
+		if end > minutesPerWeek {
+			ranges = append(ranges, [2]int{0, end - minutesPerWeek})
+			end = minutesPerWeek
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	var merged [][2]int
+	for _, r := range ranges {
+		if n := len(merged); n > 0 && r[0] <= merged[n-1][1] {
+			merged[n-1][1] = max(merged[n-1][1], r[1])
+			continue
+		}
+		merged = append(merged, r)
+	}
+	return merged
+}
+
+func inRanges(ranges [][2]int, m int) bool {
+	for _, r := range ranges {
+		if m >= r[0] && m < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// ActiveAt reports whether t falls inside any window.
+func (s ScheduleConfig) ActiveAt(t time.Time) bool {
+	return inRanges(s.weekRanges(), minuteOfWeek(t))
+}
+
+// NextTransition returns the first minute after t where ActiveAt changes.
+// ok is false when the schedule never changes state (no windows, or always on).
+func (s ScheduleConfig) NextTransition(t time.Time) (next time.Time, active bool, ok bool) {
+	ranges := s.weekRanges()
+	m := minuteOfWeek(t)
+	cur := inRanges(ranges, m)
+	if len(ranges) == 0 {
+		return time.Time{}, cur, false
+	}
+	// Every range boundary flips the state, except the week seam when one
+	// range ends there and another starts there.
+	seam := ranges[0][0] == 0 && ranges[len(ranges)-1][1] == minutesPerWeek
+	var flips []int
+	for _, r := range ranges {
+		if r[0] != 0 || !seam {
+			flips = append(flips, r[0])
+		}
+		if r[1] != minutesPerWeek || !seam {
+			flips = append(flips, r[1]%minutesPerWeek)
+		}
+	}
+	if len(flips) == 0 {
+		return time.Time{}, cur, false
+	}
+	wait := minutesPerWeek
+	for _, f := range flips {
+		if d := (f - m + minutesPerWeek) % minutesPerWeek; d > 0 && d < wait {
+			wait = d
+		}
+	}
+	// Count in wall-clock minutes so windows keep their local times across
+	// DST changes.
+	y, mo, d := t.Date()
+	next = time.Date(y, mo, d, t.Hour(), t.Minute()+wait, 0, 0, t.Location())
+	return next, !cur, true
+}
+
+// FormatDay renders the windows of one weekday as "HH:MM-HH:MM, ...".
+func (s ScheduleConfig) FormatDay(day time.Weekday) string {
+	var parts []string
+	for _, w := range s.Windows {
+		if w.Day == day {
+			parts = append(parts, w.Start+"-"+w.End)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// parseDayWindows parses the editor syntax "22:00-06:00, 12:00-13:00".
+func parseDayWindows(day time.Weekday, text string) ([]ScheduleWindow, error) {
+	var res []ScheduleWindow
+	for _, part := range strings.Split(text, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		start, end, ok := strings.Cut(part, "-")
+		if !ok {
+			return nil, fmt.Errorf("%s: invalid window %q (expected HH:MM-HH:MM)", day, part)
+		}
+		w := ScheduleWindow{Day: day, Start: strings.TrimSpace(start), End: strings.TrimSpace(end)}
+		if _, _, err := w.span(); err != nil {
+			return nil, fmt.Errorf("%s: %w", day, err)
+		}
+		// Normalize "9:00" to "09:00" so windows sort and display consistently.
+		startMin, _ := parseClock(w.Start)
+		endMin, _ := parseClock(w.End)
+		w.Start, w.End = formatClock(startMin), formatClock(endMin)
+		res = append(res, w)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Start < res[j].Start })
+	return res, nil
+}
+
+type scheduleAction int
+
+const (
+	scheduleNone scheduleAction = iota
+	scheduleStart
+	scheduleStop
+)
+
+type scheduleStatus struct {
+	Enabled       bool
+	Active        bool
+	Next          time.Time
+	NextActive    bool
+	HasNext       bool
+	OverrideUntil time.Time
+}
+
+// miningScheduler turns a ScheduleConfig into start/stop actions. It only acts
+// at window boundaries, so a manual start or stop holds until the next one.
+// The clock is injected to keep it deterministic.
+type miningScheduler struct {
+	now func() time.Time
+
+	mu       sync.Mutex
+	schedule ScheduleConfig
+	primed   bool
+	active   bool
+	override time.Time
+}
+
+func newMiningScheduler(now func() time.Time) *miningScheduler {
+	if now == nil {
+		now = time.Now
+	}
+	return &miningScheduler{now: now}
+}
+
+// SetSchedule replaces the schedule. The next Tick re-applies the current
+// window state.
+func (s *miningScheduler) SetSchedule(cfg ScheduleConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.schedule = cfg
+	s.primed = false
+	s.override = time.Time{}
+}
+
+// Tick evaluates the schedule and returns the action due now, if any.
+func (s *miningScheduler) Tick() scheduleAction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.schedule.Enabled {
+		s.primed = false
+		return scheduleNone
+	}
+	now := s.now()
+	active := s.schedule.ActiveAt(now)
+	if s.primed && active == s.active {
+		return scheduleNone
+	}
+	s.primed = true
+	s.active = active
+	s.override = time.Time{}
+	if active {
+		return scheduleStart
+	}
+	return scheduleStop
+}
+
+// Override marks the current scheduled state as overridden until the next
+// boundary and returns the action the caller should perform.
+func (s *miningScheduler) Override() (scheduleAction, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.schedule.Enabled {
+		return scheduleNone, time.Time{}
+	}
+	now := s.now()
+	next, _, ok := s.schedule.NextTransition(now)
+	if !ok {
+		return scheduleNone, time.Time{}
+	}
+	s.override = next
+	if s.schedule.ActiveAt(now) {
+		return scheduleStop, next
+	}
+	return scheduleStart, next
+}
+
+func (s *miningScheduler) Status() scheduleStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	st := scheduleStatus{Enabled: s.schedule.Enabled}
+	if !st.Enabled {
+		return st
+	}
+	st.Active = s.schedule.ActiveAt(now)
+	st.Next, st.NextActive, st.HasNext = s.schedule.NextTransition(now)
+	if now.Before(s.override) {
+		st.OverrideUntil = s.override
+	}
+	return st
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// 2024-01-01 is a Monday; 2024-01-06 and 2024-01-07 are Saturday and Sunday.
+func at(day, hour, min int) time.Time {
+	return time.Date(2024, 1, day, hour, min, 0, 0, time.UTC)
+}
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func TestScheduleActiveAt(t *testing.T) {
+	overnight := ScheduleConfig{Enabled: true, Windows: []ScheduleWindow{
+		{Day: time.Monday, Start: "22:00", End: "06:00"},
+	}}
+	weekWrap := ScheduleConfig{Enabled: true, Windows: []ScheduleWindow{
+		{Day: time.Saturday, Start: "22:00", End: "02:00"},
+	}}
+	toMidnight := ScheduleConfig{Enabled: true, Windows: []ScheduleWindow{
+		{Day: time.Friday, Start: "18:00", End: "24:00"},
+	}}
+
+	tests := []struct {
+		name     string
+		schedule ScheduleConfig
+		t        time.Time
+		want     bool
+	}{
+		{"overnight before start", overnight, at(1, 21, 59), false},
+		{"overnight at start", overnight, at(1, 22, 0), true},
+		{"overnight after midnight", overnight, at(2, 5, 59), true},
+		{"overnight at end", overnight, at(2, 6, 0), false},
+		{"overnight other day", overnight, at(3, 23, 0), false},
+		{"week wrap saturday", weekWrap, at(6, 23, 30), true},
+		{"week wrap sunday", weekWrap, at(7, 1, 59), true},
+		{"week wrap at end", weekWrap, at(7, 2, 0), false},
+		{"week wrap previous sunday", weekWrap, at(7, 3, 0), false},
+		{"until 24:00 start", toMidnight, at(5, 18, 0), true},
+		{"until 24:00 last minute", toMidnight, at(5, 23, 59), true},
+		{"until 24:00 midnight", toMidnight, at(6, 0, 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.schedule.ActiveAt(tt.t); got != tt.want {
+			t.Errorf("%s: ActiveAt(%s) = %v, want %v", tt.name, tt.t.Format("Mon 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestScheduleNextTransition(t *testing.T) {
+	var allDay []ScheduleWindow
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		allDay = append(allDay, ScheduleWindow{Day: d, Start: "00:00", End: "24:00"})
+	}
+	office := []ScheduleWindow{{Day: time.Monday, Start: "09:00", End: "17:00"}}
+
+	tests := []struct {
+		name       string
+		windows    []ScheduleWindow
+		t          time.Time
+		wantNext   time.Time
+		wantActive bool
+		wantOK     bool
+	}{
+		{"always on", allDay, at(3, 12, 0), time.Time{}, true, false},
+		{"no windows", nil, at(3, 12, 0), time.Time{}, false, false},
+		{"before window", office, at(1, 8, 30), at(1, 9, 0), true, true},
+		{"inside window", office, at(1, 9, 0), at(1, 17, 0), false, true},
+		{"next week", office, at(1, 17, 0), at(8, 9, 0), true, true},
+	}
+	for _, tt := range tests {
+		s := ScheduleConfig{Enabled: true, Windows: tt.windows}
+		next, active, ok := s.NextTransition(tt.t)
+		if !next.Equal(tt.wantNext) || active != tt.wantActive || ok != tt.wantOK {
+			t.Errorf("%s: NextTransition = (%v, %v, %v), want (%v, %v, %v)",
+				tt.name, next, active, ok, tt.wantNext, tt.wantActive, tt.wantOK)
+		}
+	}
+}
+
+// TestScheduleNextTransitionMatchesScan checks NextTransition against a
+// minute-by-minute scan of ActiveAt.
+func TestScheduleNextTransitionMatchesScan(t *testing.T) {
+	schedules := []ScheduleConfig{
+		{Windows: []ScheduleWindow{
+			{Day: time.Monday, Start: "22:00", End: "06:00"},
+			{Day: time.Tuesday, Start: "05:00", End: "07:30"},
+			{Day: time.Tuesday, Start: "07:30", End: "08:00"},
+			{Day: time.Thursday, Start: "12:00", End: "13:00"},
+		}},
+		// Wraps the week and touches the window starting Sunday 00:00.
+		{Windows: []ScheduleWindow{
+			{Day: time.Saturday, Start: "20:00", End: "24:00"},
+			{Day: time.Sunday, Start: "00:00", End: "03:00"},
+		}},
+		{Windows: []ScheduleWindow{
+			{Day: time.Saturday, Start: "23:00", End: "01:00"},
+			{Day: time.Wednesday, Start: "bad", End: "01:00"},
+		}},
+	}
+	for i, s := range schedules {
+		for _, now := range []time.Time{at(1, 0, 0), at(1, 23, 59), at(2, 5, 30), at(4, 12, 0), at(6, 21, 15), at(7, 0, 0), at(7, 2, 59)} {
+			var want time.Time
+			cur := s.ActiveAt(now)
+			for m := 1; m <= minutesPerWeek; m++ {
+				if tt := now.Add(time.Duration(m) * time.Minute); s.ActiveAt(tt) != cur {
+					want = tt
+					break
+				}
+			}
+			next, active, ok := s.NextTransition(now)
+			if !ok || !next.Equal(want) || active == cur {
+				t.Errorf("schedule %d at %s: NextTransition = %s, %v, %v; want %s, %v", i, now, next, active, ok, want, !cur)
+			}
+		}
+	}
+}
+
+func TestSchedulerTick(t *testing.T) {
+	office := ScheduleConfig{Enabled: true, Windows: []ScheduleWindow{
+		{Day: time.Monday, Start: "09:00", End: "17:00"},
+	}}
+
+	tests := []struct {
+		name  string
+		times []time.Time
+		want  []scheduleAction
+	}{
+		{"primes inside window", []time.Time{at(1, 10, 0)}, []scheduleAction{scheduleStart}},
+		{"primes outside window", []time.Time{at(1, 8, 0)}, []scheduleAction{scheduleStop}},
+		{
+			"quiet inside window",
+			[]time.Time{at(1, 9, 0), at(1, 9, 1), at(1, 12, 0), at(1, 16, 59)},
+			[]scheduleAction{scheduleStart, scheduleNone, scheduleNone, scheduleNone},
+		},
+		{
+			"acts at boundaries",
+			[]time.Time{at(1, 8, 59), at(1, 9, 0), at(1, 17, 0), at(1, 18, 0)},
+			[]scheduleAction{scheduleStop, scheduleStart, scheduleStop, scheduleNone},
+		},
+	}
+	for _, tt := range tests {
+		clock := &fakeClock{}
+		s := newMiningScheduler(clock.now)
+		s.SetSchedule(office)
+		for i, now := range tt.times {
+			clock.t = now
+			if got := s.Tick(); got != tt.want[i] {
+				t.Errorf("%s: Tick at %s = %v, want %v", tt.name, now.Format("Mon 15:04"), got, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestSchedulerTickDisabled(t *testing.T) {
+	clock := &fakeClock{t: at(1, 10, 0)}
+	s := newMiningScheduler(clock.now)
+	s.SetSchedule(ScheduleConfig{Windows: []ScheduleWindow{{Day: time.Monday, Start: "09:00", End: "17:00"}}})
+	if got := s.Tick(); got != scheduleNone {
+		t.Errorf("Tick with disabled schedule = %v, want none", got)
+	}
+}
+
+func TestSchedulerOverride(t *testing.T) {
+	office := ScheduleConfig{Enabled: true, Windows: []ScheduleWindow{
+		{Day: time.Monday, Start: "09:00", End: "17:00"},
+	}}
+	clock := &fakeClock{t: at(1, 10, 0)}
+	s := newMiningScheduler(clock.now)
+	s.SetSchedule(office)
+	if got := s.Tick(); got != scheduleStart {
+		t.Fatalf("first Tick = %v, want start", got)
+	}
+
+	action, until := s.Override()
+	if action != scheduleStop || !until.Equal(at(1, 17, 0)) {
+		t.Fatalf("Override = (%v, %v), want (stop, 17:00)", action, until)
+	}
+
+	// The override holds for the rest of the window.
+	for _, now := range []time.Time{at(1, 10, 1), at(1, 16, 59)} {
+		clock.t = now
+		if got := s.Tick(); got != scheduleNone {
+			t.Errorf("Tick at %s during override = %v, want none", now.Format("15:04"), got)
+		}
+		if st := s.Status(); !st.OverrideUntil.Equal(until) {
+			t.Errorf("Status at %s: OverrideUntil = %v, want %v", now.Format("15:04"), st.OverrideUntil, until)
+		}
+	}
+
+	// The boundary applies the schedule again and clears the override.
+	clock.t = at(1, 17, 0)
+	if got := s.Tick(); got != scheduleStop {
+		t.Errorf("Tick at boundary = %v, want stop", got)
+	}
+	if !s.override.IsZero() {
+		t.Errorf("override = %v after boundary, want cleared", s.override)
+	}
+	if st := s.Status(); !st.OverrideUntil.IsZero() {
+		t.Errorf("Status after boundary: OverrideUntil = %v, want zero", st.OverrideUntil)
+	}
+
+	// Outside a window, an override starts mining until the window opens.
+	clock.t = at(2, 8, 0)
+	if action, until := s.Override(); action != scheduleStart || !until.Equal(at(8, 9, 0)) {
+		t.Errorf("Override outside window = (%v, %v), want (start, next Monday 09:00)", action, until)
+	}
+}
+
+func TestSchedulerOverrideNeverChanges(t *testing.T) {
+	clock := &fakeClock{t: at(1, 10, 0)}
+	s := newMiningScheduler(clock.now)
+	var allDay []ScheduleWindow
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		allDay = append(allDay, ScheduleWindow{Day: d, Start: "00:00", End: "24:00"})
+	}
+	s.SetSchedule(ScheduleConfig{Enabled: true, Windows: allDay})
+	if action, _ := s.Override(); action != scheduleNone {
+		t.Errorf("Override on always-on schedule = %v, want none", action)
+	}
+}
+
+func TestSchedulerSetScheduleReprimes(t *testing.T) {
+	office := ScheduleConfig{Enabled: true, Windows: []ScheduleWindow{
+		{Day: time.Monday, Start: "09:00", End: "17:00"},
+	}}
+	clock := &fakeClock{t: at(1, 10, 0)}
+	s := newMiningScheduler(clock.now)
+	s.SetSchedule(office)
+	if got := s.Tick(); got != scheduleStart {
+		t.Fatalf("first Tick = %v, want start", got)
+	}
+	s.Override()
+	if got := s.Tick(); got != scheduleNone {
+		t.Fatalf("Tick during override = %v, want none", got)
+	}
+
+	// Saving the same schedule again re-applies the current state.
+	s.SetSchedule(office)
+	if !s.override.IsZero() {
+		t.Errorf("override = %v after SetSchedule, want cleared", s.override)
+	}
+	if got := s.Tick(); got != scheduleStart {
+		t.Errorf("Tick after SetSchedule = %v, want start", got)
+	}
+
+	// A new schedule that no longer covers now stops mining.
+	s.SetSchedule(ScheduleConfig{Enabled: true, Windows: []ScheduleWindow{
+		{Day: time.Tuesday, Start: "09:00", End: "17:00"},
+	}})
+	if got := s.Tick(); got != scheduleStop {
+		t.Errorf("Tick after moving the window = %v, want stop", got)
+	}
+	if got := s.Tick(); got != scheduleNone {
+		t.Errorf("second Tick after SetSchedule = %v, want none", got)
+	}
+}
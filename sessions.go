@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	sessionsFileName = "sessions.jsonl"
+	// maxSessions is how many records the log keeps; older ones are dropped
+	// when the app starts.
+	maxSessions = 1000
+)
+
+// sessionRecord summarizes one ethminer process, from start to cmd.Wait.
+// Hashrates are in MH/s; share counts are the miner's totals at the last
+// successful poll.
+type sessionRecord struct {
+	Start        time.Time `json:"start"`
+	End          time.Time `json:"end"`
+	Mode         string    `json:"mode"`
+	Instance     string    `json:"instance"`
+	Backend      string    `json:"backend"`
+	Devices      []int     `json:"devices,omitempty"`
+	Pool         string    `json:"pool,omitempty"`
+	AvgMHs       float64   `json:"avgMhs"`
+	PeakMHs      float64   `json:"peakMhs"`
+	Accepted     int64     `json:"accepted"`
+	Rejected     int64     `json:"rejected"`
+	Invalid      int64     `json:"invalid"`
+	PoolSwitches int64     `json:"poolSwitches"`
+	ExitReason   string    `json:"exitReason"`
+	ExitCode     int       `json:"exitCode"`
+}
+
+func (r sessionRecord) Duration() time.Duration {
+	return r.End.Sub(r.Start)
+}
+
+// sessionStats accumulates the polled hashrate of one process.
+type sessionStats struct {
+	Start   time.Time
+	SumKHs  float64
+	Polls   int
+	PeakKHs int64
+	Last    Stat
+	HasLast bool
+}
+
+func (s *sessionStats) Add(st Stat) {
+	s.SumKHs += float64(st.TotalKHs)
+	s.Polls++
+	s.PeakKHs = max(s.PeakKHs, st.TotalKHs)
+	s.Last, s.HasLast = st, true
+}
+
+// exitReason describes why a miner process ended. Processes asked to exit
+// (stop button, schedule, REST API, quit) count as stopped whatever their
+// exit status.
+func exitReason(stopping bool, err error) string {
+	switch {
+	case stopping:
+		return "stopped"
+	case err == nil:
+		return "exited"
+	default:
+		return "crashed: " + err.Error()
+	}
+}
+
+func newSessionRecord(inst *minerInstance, mode, poolURL string, end time.Time, waitErr error) sessionRecord {
+	s := inst.session
+	r := sessionRecord{
+		Start:      s.Start,
+		End:        end,
+		Mode:       mode,
+		Instance:   inst.Name,
+		Backend:    inst.Backend,
+		Devices:    append([]int(nil), inst.Devices...),
+		Pool:       poolHost(poolURL),
+		PeakMHs:    float64(s.PeakKHs) / 1000,
+		ExitReason: exitReason(inst.stopping, waitErr),
+		ExitCode:   exitCodeOf(waitErr),
+	}
+	if s.Polls > 0 {
+		r.AvgMHs = s.SumKHs / float64(s.Polls) / 1000
+	}
+	if s.HasLast {
+		if s.Last.Pool != "" {
+			r.Pool = poolHost(s.Last.Pool)
+		}
+		r.Accepted, r.Rejected, r.Invalid = s.Last.Accepted, s.Last.Rejected, s.Last.Invalid
+		r.PoolSwitches = s.Last.PoolSwitches
+	}
+	return r
+}
+
+// sessionLog is the on-disk list of session records, oldest first.
+type sessionLog struct {
+	path string
+
+	mu      sync.Mutex
+	records []sessionRecord
+}
+
+func sessionsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configDirName, sessionsFileName), nil
+}
+
+// openSessionLog loads path, trimming it to the newest maxSessions records.
+func openSessionLog(path string) (*sessionLog, error) {
+	l := &sessionLog{path: path}
+	f, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var r sessionRecord
+		if json.Unmarshal(sc.Bytes(), &r) == nil && !r.Start.IsZero() {
+			l.records = append(l.records, r)
+		}
+	}
+	f.Close()
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(l.records) > maxSessions {
+		l.records = append([]sessionRecord(nil), l.records[len(l.records)-maxSessions:]...)
+		if err := l.rewrite(); err != nil {
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+func (l *sessionLog) rewrite() error {
+	tmp := l.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range l.records {
+		if err = enc.Encode(r); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, l.path)
+}
+
+// Append stores r at the end of the log.
+func (l *sessionLog) Append(r sessionRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.records = append(l.records, r)
+	if len(l.records) > maxSessions {
+		l.records = l.records[len(l.records)-maxSessions:]
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o700); err != nil {
+		return err
+	}
+	b, _ := json.Marshal(r)
+	return appendLine(l.path, b)
+}
+
+// Records returns the stored sessions, oldest first.
+func (l *sessionLog) Records() []sessionRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]sessionRecord(nil), l.records...)
+}
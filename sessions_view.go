@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxSessionRows bounds the rows built for the Sessions tab; the log itself
+// keeps more.
+const maxSessionRows = 200
+
+var sessionColumns = []string{"Started", "Duration", "Mode", "Backend", "Pool", "Avg / peak", "Shares", "Switches", "Exit"}
+
+// sessionsView is the "Sessions" tab: one row per finished miner process,
+// newest first, with totals over the listed sessions.
+type sessionsView struct {
+	records []sessionRecord
+
+	rows      *fyne.Container
+	empty     *widget.Label
+	totalRuns *widget.Label
+	totalTime *widget.Label
+	totalShr  *widget.Label
+	view      fyne.CanvasObject
+}
+
+func newSessionsView(records []sessionRecord) *sessionsView {
+	s := &sessionsView{
+		records:   append([]sessionRecord(nil), records...),
+		rows:      container.NewVBox(),
+		empty:     widget.NewLabel("No sessions yet. A summary is recorded each time a miner process exits."),
+		totalRuns: widget.NewLabelWithStyle("—", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+		totalTime: widget.NewLabel("—"),
+		totalShr:  widget.NewLabel("—"),
+	}
+	s.empty.Wrapping = fyne.TextWrapWord
+
+	var header []fyne.CanvasObject
+	for _, c := range sessionColumns {
+		l := widget.NewLabelWithStyle(c, fyne.TextAlignLeading, fyne.TextStyle{Italic: true})
+		l.Truncation = fyne.TextTruncateEllipsis
+		header = append(header, l)
+	}
+	totals := container.NewGridWithColumns(3,
+		metricTileWithIcon("Sessions", theme.ListIcon(), s.totalRuns),
+		metricTileWithIcon("Mining time", theme.HistoryIcon(), s.totalTime),
+		metricTileWithIcon("Shares", theme.ConfirmIcon(), s.totalShr),
+	)
+	table := container.NewVBox(container.NewGridWithColumns(len(header), header...), widget.NewSeparator(), s.empty, s.rows)
+	s.view = panel("Sessions", container.NewBorder(totals, nil, nil, nil, container.NewVScroll(table)))
+	s.render()
+	return s
+}
+
+func (s *sessionsView) Object() fyne.CanvasObject {
+	return s.view
+}
+
+// Add shows a new session. It must be called on the UI goroutine.
+func (s *sessionsView) Add(r sessionRecord) {
+	s.records = append(s.records, r)
+	if len(s.records) > maxSessions {
+		s.records = s.records[len(s.records)-maxSessions:]
+	}
+	s.render()
+}
+
+func (s *sessionsView) render() {
+	var (
+		objs     []fyne.CanvasObject
+		total    time.Duration
+		acc, rej int64
+	)
+	for i := len(s.records) - 1; i >= 0; i-- {
+		r := s.records[i]
+		total += r.Duration()
+		acc += r.Accepted
+		rej += r.Rejected
+		if len(objs) < maxSessionRows {
+			objs = append(objs, sessionRow(r))
+		}
+	}
+	s.rows.Objects = objs
+	s.rows.Refresh()
+	if len(s.records) == 0 {
+		s.empty.Show()
+		s.totalRuns.SetText("—")
+		s.totalTime.SetText("—")
+		s.totalShr.SetText("—")
+		return
+	}
+	s.empty.Hide()
+	s.totalRuns.SetText(fmt.Sprintf("%d", len(s.records)))
+	s.totalTime.SetText(formatSessionDuration(total))
+	s.totalShr.SetText(fmt.Sprintf("Accepted %d | Rejected %d", acc, rej))
+}
+
+func sessionRow(r sessionRecord) fyne.CanvasObject {
+	cell := func(text string) *widget.Label {
+		l := widget.NewLabelWithStyle(text, fyne.TextAlignLeading, fyne.TextStyle{Monospace: true})
+		l.Truncation = fyne.TextTruncateEllipsis
+		return l
+	}
+	backend := backendDisplayName(r.Backend)
+	if len(r.Devices) > 0 {
+		backend += fmt.Sprintf(" ×%d", len(r.Devices))
+	}
+	exit := cell(r.ExitReason)
+	if strings.HasPrefix(r.ExitReason, "crashed") {
+		exit.Importance = widget.DangerImportance
+	}
+	cols := []fyne.CanvasObject{
+		cell(r.Start.Local().Format("Mon 02 Jan 15:04")),
+		cell(formatSessionDuration(r.Duration())),
+		cell(orDash(r.Mode)),
+		cell(backend),
+		cell(orDash(r.Pool)),
+		cell(fmt.Sprintf("%.2f / %.2f", r.AvgMHs, r.PeakMHs)),
+		cell(fmt.Sprintf("A %d | R %d | I %d", r.Accepted, r.Rejected, r.Invalid)),
+		cell(fmt.Sprintf("%d", r.PoolSwitches)),
+		exit,
+	}
+	return container.NewGridWithColumns(len(cols), cols...)
+}
+
+// formatSessionDuration renders d as "3h 04m" or "12m 30s".
+func formatSessionDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := int(d / time.Hour)
+	m := int(d % time.Hour / time.Minute)
+	sec := int(d % time.Minute / time.Second)
+	if h > 0 {
+		return fmt.Sprintf("%dh %02dm", h, m)
+	}
+	return fmt.Sprintf("%dm %02ds", m, sec)
+}
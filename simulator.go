@@ -0,0 +1,574 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The simulator is this executable re-run as a fake ethminer. The GUI sets
+// simulatorEnv for its children, so device listing, launching, the API and
+// crash handling all go through the same code paths as a real miner.
+const (
+	simulatorEnv     = "OLIVETUM_SIMULATOR"
+	simulatorVersion = "0.19.0-olivetum-sim"
+
+	simDAGTime = 4 * time.Second
+)
+
+// simulatorPath is the executable standing in for ethminer, or "" when the
+// simulator is off. It is trusted without a digest check.
+var simulatorPath string
+
+// simConfig holds the simulated rig's behavior. It travels to the child
+// process as OLIVETUM_SIM_* variables.
+type simConfig struct {
+	GPUs       int
+	MHs        float64
+	Temp       int
+	RejectRate float64
+	CrashAfter time.Duration
+	Detail     bool
+}
+
+func defaultSimConfig() simConfig {
+	return simConfig{GPUs: 3, MHs: 30, Temp: 64, RejectRate: 0.02, Detail: true}
+}
+
+// registerSimFlags adds the -sim-* flags that tune the simulator.
+func registerSimFlags(fs *flag.FlagSet, c *simConfig) {
+	fs.IntVar(&c.GPUs, "sim-gpus", c.GPUs, "simulator: number of GPUs (NVIDIA first, then AMD)")
+	fs.Float64Var(&c.MHs, "sim-mhs", c.MHs, "simulator: hashrate per GPU in MH/s")
+	fs.IntVar(&c.Temp, "sim-temp", c.Temp, "simulator: steady-state GPU temperature in °C")
+	fs.Float64Var(&c.RejectRate, "sim-reject", c.RejectRate, "simulator: fraction of shares rejected (0..1)")
+	fs.DurationVar(&c.CrashAfter, "sim-crash", c.CrashAfter, "simulator: crash the miner after this long (0 = never)")
+	fs.BoolVar(&c.Detail, "sim-detail", c.Detail, "simulator: answer miner_getstatdetail (false exercises the getstat1 fallback)")
+}
+
+func (c simConfig) env() map[string]string {
+	return map[string]string{
+		"OLIVETUM_SIM_GPUS":   strconv.Itoa(c.GPUs),
+		"OLIVETUM_SIM_MHS":    strconv.FormatFloat(c.MHs, 'f', -1, 64),
+		"OLIVETUM_SIM_TEMP":   strconv.Itoa(c.Temp),
+		"OLIVETUM_SIM_REJECT": strconv.FormatFloat(c.RejectRate, 'f', -1, 64),
+		"OLIVETUM_SIM_CRASH":  c.CrashAfter.String(),
+		"OLIVETUM_SIM_DETAIL": strconv.FormatBool(c.Detail),
+	}
+}
+
+func simConfigFromEnv() simConfig {
+	c := defaultSimConfig()
+	if v, err := strconv.Atoi(os.Getenv("OLIVETUM_SIM_GPUS")); err == nil && v >= 1 && v <= 16 {
+		c.GPUs = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("OLIVETUM_SIM_MHS"), 64); err == nil && v >= 0 {
+		c.MHs = v
+	}
+	if v, err := strconv.Atoi(os.Getenv("OLIVETUM_SIM_TEMP")); err == nil && v > 0 {
+		c.Temp = v
+	}
+	if v, err := strconv.ParseFloat(os.Getenv("OLIVETUM_SIM_REJECT"), 64); err == nil && v >= 0 && v <= 1 {
+		c.RejectRate = v
+	}
+	if v, err := time.ParseDuration(os.Getenv("OLIVETUM_SIM_CRASH")); err == nil && v >= 0 {
+		c.CrashAfter = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("OLIVETUM_SIM_DETAIL")); err == nil {
+		c.Detail = v
+	}
+	return c
+}
+
+// enableSimulator points the GUI at the simulator for this session.
+func enableSimulator(c simConfig) (ethminerInfo, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return ethminerInfo{}, fmt.Errorf("simulator: %w", err)
+	}
+	if err := os.Setenv(simulatorEnv, "1"); err != nil {
+		return ethminerInfo{}, fmt.Errorf("simulator: %w", err)
+	}
+	for k, v := range c.env() {
+		_ = os.Setenv(k, v)
+	}
+	simulatorPath = exe
+	info, err := probeEthminer(exe)
+	info.Integrity = integritySimulated
+	if err != nil {
+		return info, err
+	}
+	return info, info.compatible()
+}
+
+type simGPU struct {
+	Name   string
+	PCI    string
+	NVIDIA bool
+}
+
+func simGPUs(n int) []simGPU {
+	nvidia := (n + 1) / 2
+	res := make([]simGPU, 0, n)
+	for i := 0; i < n; i++ {
+		g := simGPU{PCI: fmt.Sprintf("%02x:00.0", i+1)}
+		if i < nvidia {
+			g.Name, g.NVIDIA = "Simulated GeForce RTX 3070", true
+		} else {
+			g.Name = "Simulated Radeon RX 6800"
+		}
+		res = append(res, g)
+	}
+	return res
+}
+
+// simBackendGPUs lists the GPUs a backend sees: CUDA only NVIDIA cards,
+// OpenCL every card, as on a real mixed rig.
+func simBackendGPUs(all []simGPU, cuda bool) []simGPU {
+	if !cuda {
+		return all
+	}
+	var res []simGPU
+	for _, g := range all {
+		if g.NVIDIA {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+// runSimulator is the fake ethminer's main. It returns the exit code.
+func runSimulator(args []string) int {
+	cfg := simConfigFromEnv()
+	cuda := false
+	var (
+		pools     []string
+		apiBind   string
+		password  string
+		selection []int
+		display   = 10
+	)
+	for i := 0; i < len(args); i++ {
+		next := func() string {
+			if i+1 < len(args) {
+				i++
+				return args[i]
+			}
+			return ""
+		}
+		switch args[i] {
+		case "--version":
+			fmt.Println("ethminer " + simulatorVersion)
+			fmt.Println("Build: simulator/olivetum")
+			return 0
+		case "--help", "-h":
+			fmt.Println("ethminer " + simulatorVersion + " (simulator)")
+			fmt.Println("  --olivetum         Mine Olivetumhash")
+			fmt.Println("  --list-devices     List the detected devices and exit")
+			return 0
+		case "-U":
+			cuda = true
+		case "-G":
+			cuda = false
+		case "--list-devices":
+			simListDevices(simBackendGPUs(simGPUs(cfg.GPUs), cuda), cuda)
+			return 0
+		case "-P":
+			pools = append(pools, next())
+		case "--api-bind":
+			apiBind = next()
+		case "--api-password":
+			password = next()
+		case "--display-interval":
+			if v, err := strconv.Atoi(next()); err == nil && v > 0 {
+				display = v
+			}
+		case "--cu-devices", "--cl-devices":
+			for i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+				if v, err := strconv.Atoi(args[i]); err == nil {
+					selection = append(selection, v)
+				}
+			}
+		}
+	}
+	if len(pools) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No pool definitions provided (-P)")
+		return 1
+	}
+
+	visible := simBackendGPUs(simGPUs(cfg.GPUs), cuda)
+	var gpus []simGPU
+	if len(selection) == 0 {
+		gpus = visible
+	} else {
+		for _, idx := range selection {
+			if idx >= 0 && idx < len(visible) {
+				gpus = append(gpus, visible[idx])
+			}
+		}
+	}
+	if len(gpus) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: No usable mining devices found")
+		return 1
+	}
+
+	m := newSimMiner(cfg, gpus, pools, cuda)
+	if apiBind != "" {
+		if err := m.serveAPI(apiBind, password); err != nil {
+			m.log("X", "main", "API: "+err.Error())
+			return 1
+		}
+	}
+	return m.run(time.Duration(display) * time.Second)
+}
+
+func simListDevices(gpus []simGPU, cuda bool) {
+	fmt.Println(" Id Pci Id    Type Name                          CUDA SM   Total Memory")
+	fmt.Println(" --- --------- ---- ----------------------------- ---- ---  ------------")
+	for i, g := range gpus {
+		cudaCol, sm := "No", "   "
+		if cuda {
+			cudaCol, sm = "Yes", "8.6"
+		}
+		fmt.Printf(" %-3d %-9s Gpu  %-29s %-4s %s  8.00 GB\n", i, g.PCI, g.Name, cudaCol, sm)
+	}
+}
+
+type simDevice struct {
+	simGPU
+	KHs      float64
+	Temp     float64
+	Fan      int
+	Accepted int64
+	Rejected int64
+	Paused   bool
+}
+
+type simMiner struct {
+	cfg   simConfig
+	cuda  bool
+	pools []string
+	start time.Time
+	rng   *rand.Rand
+
+	mu       sync.Mutex
+	devices  []*simDevice
+	active   int
+	switches int64
+	dagReady time.Time
+}
+
+func newSimMiner(cfg simConfig, gpus []simGPU, pools []string, cuda bool) *simMiner {
+	m := &simMiner{
+		cfg:   cfg,
+		cuda:  cuda,
+		pools: pools,
+		start: time.Now(),
+		rng:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for _, g := range gpus {
+		m.devices = append(m.devices, &simDevice{simGPU: g, Temp: 35, Fan: 30})
+	}
+	m.dagReady = m.start.Add(simDAGTime)
+	return m
+}
+
+func (m *simMiner) log(level, channel, msg string) {
+	fmt.Printf(" %s %s %-8s %s\n", level, time.Now().Format("15:04:05"), channel, msg)
+}
+
+func (m *simMiner) devPrefix() string {
+	if m.cuda {
+		return "cu"
+	}
+	return "cl"
+}
+
+func (m *simMiner) run(display time.Duration) int {
+	m.log("i", "main", "ethminer "+simulatorVersion)
+	m.log("i", "main", "Build: simulator/olivetum")
+	for i, d := range m.devices {
+		m.log("i", fmt.Sprintf("%s-%d", m.devPrefix(), i), fmt.Sprintf("Using Pci Id : %s %s (simulated)", d.PCI, d.Name))
+	}
+	m.log("i", "stratum", "Connected to "+poolHost(m.pools[0]))
+	m.log("i", "<wp>", "Epoch : 42 Difficulty : 4.00 Gh")
+	m.log("i", "<wp>", "Generating DAG + Light : 1.51 GB")
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt)
+	tick := time.NewTicker(time.Second)
+	defer tick.Stop()
+	var crash <-chan time.Time
+	if m.cfg.CrashAfter > 0 {
+		crash = time.After(m.cfg.CrashAfter)
+	}
+	lastDisplay := time.Now()
+	dagLogged := false
+
+	for {
+		select {
+		case <-sig:
+			m.log("i", "main", "Got interrupt ...")
+			m.log("i", "main", "Shutting down miners...")
+			return 0
+		case <-crash:
+			m.log("X", "main", "Simulated crash: CUDA error in func 'search' at line 295 : unspecified launch failure.")
+			return 134
+		case now := <-tick.C:
+			if !dagLogged && !now.Before(m.dagReady) {
+				dagLogged = true
+				m.log("i", "<wp>", "Generated DAG + Light in 4,012 ms.")
+			}
+			for _, line := range m.step(now) {
+				m.log("i", "<unknown>", line)
+			}
+			if now.Sub(lastDisplay) >= display {
+				lastDisplay = now
+				m.log("m", "<unknown>", m.summary())
+			}
+		}
+	}
+}
+
+// step advances the simulation by one second and returns share log lines.
+func (m *simMiner) step(now time.Time) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	mining := !now.Before(m.dagReady)
+	var lines []string
+	for i, d := range m.devices {
+		target := 0.0
+		if mining && !d.Paused {
+			target = m.cfg.MHs * 1000 * (0.97 + 0.06*m.rng.Float64())
+		}
+		d.KHs = target
+		heat := 35.0
+		if target > 0 {
+			heat = float64(m.cfg.Temp)
+		}
+		d.Temp += (heat - d.Temp) * 0.1
+		d.Fan = int(math.Max(30, math.Min(100, 30+(d.Temp-40)*1.8)))
+		// About one share per GPU every 20s at full speed.
+		if target > 0 && m.rng.Float64() < 0.05 {
+			if m.rng.Float64() < m.cfg.RejectRate {
+				d.Rejected++
+				lines = append(lines, fmt.Sprintf("**Rejected %d ms. %s %s%d", 40+m.rng.Intn(60), poolHost(m.pools[m.active]), m.devPrefix(), i))
+			} else {
+				d.Accepted++
+				lines = append(lines, fmt.Sprintf("**Accepted %d ms. %s", 40+m.rng.Intn(60), poolHost(m.pools[m.active])))
+			}
+		}
+	}
+	return lines
+}
+
+func (m *simMiner) summary() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	up := time.Since(m.start)
+	var total float64
+	var acc, rej int64
+	var per []string
+	for i, d := range m.devices {
+		total += d.KHs
+		acc += d.Accepted
+		rej += d.Rejected
+		per = append(per, fmt.Sprintf("%s%d %.2f", m.devPrefix(), i, d.KHs/1000))
+	}
+	return fmt.Sprintf("%d:%02d A%d:R%d %.2f Mh - %s", int(up.Hours()), int(up.Minutes())%60, acc, rej, total/1000, strings.Join(per, ", "))
+}
+
+func (m *simMiner) serveAPI(bind, password string) error {
+	// "127.0.0.1:-3333" is ethminer's read-only form.
+	host, port, err := net.SplitHostPort(bind)
+	if err != nil {
+		return err
+	}
+	readOnly := strings.HasPrefix(port, "-")
+	ln, err := net.Listen("tcp", net.JoinHostPort(host, strings.TrimPrefix(port, "-")))
+	if err != nil {
+		return err
+	}
+	m.log("i", "main", "Api server listening on port "+strings.TrimPrefix(port, "-"))
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go m.handleAPI(conn, password, readOnly)
+		}
+	}()
+	return nil
+}
+
+type simRequest struct {
+	ID     json.RawMessage            `json:"id"`
+	Method string                     `json:"method"`
+	Params map[string]json.RawMessage `json:"params"`
+}
+
+func (m *simMiner) handleAPI(conn net.Conn, password string, readOnly bool) {
+	defer conn.Close()
+	authed := password == ""
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var req simRequest
+		resp := map[string]any{"jsonrpc": "2.0"}
+		if err := json.Unmarshal(line, &req); err != nil {
+			resp["error"] = map[string]any{"code": -32700, "message": "Parse error"}
+		} else {
+			resp["id"] = req.ID
+			switch {
+			case req.Method == "api_authorize":
+				var psw string
+				_ = json.Unmarshal(req.Params["psw"], &psw)
+				authed = password == "" || psw == password
+				if authed {
+					resp["result"] = true
+				} else {
+					resp["error"] = map[string]any{"code": -401, "message": "Invalid password"}
+				}
+			case !authed:
+				resp["error"] = map[string]any{"code": -403, "message": "Authorization needed"}
+			default:
+				result, err := m.call(req, readOnly)
+				if err != nil {
+					resp["error"] = err
+				} else {
+					resp["result"] = result
+				}
+			}
+		}
+		b, _ := json.Marshal(resp)
+		if _, err := conn.Write(append(b, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+func (m *simMiner) call(req simRequest, readOnly bool) (any, *rpcError) {
+	writes := map[string]bool{
+		"miner_restart": true, "miner_reboot": true, "miner_pausegpu": true,
+		"miner_setverbosity": true, "miner_setactiveconnection": true,
+	}
+	if readOnly && writes[req.Method] {
+		return nil, &rpcError{Code: -32601, Message: "Method not available"}
+	}
+	intParam := func(name string) (int, bool) {
+		var v int
+		err := json.Unmarshal(req.Params[name], &v)
+		return v, err == nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch req.Method {
+	case "miner_ping":
+		return "pong", nil
+	case "miner_getstat1":
+		return encodeStat1(m.stat()), nil
+	case "miner_getstatdetail":
+		if !m.cfg.Detail {
+			break
+		}
+		return encodeStatDetail(m.stat()), nil
+	case "miner_getconnections":
+		var conns []poolConnection
+		for i, p := range m.pools {
+			conns = append(conns, poolConnection{Index: i, Active: i == m.active, URI: p})
+		}
+		return conns, nil
+	case "miner_setactiveconnection":
+		idx, ok := intParam("index")
+		if !ok || idx < 0 || idx >= len(m.pools) {
+			return nil, &rpcError{Code: -422, Message: "Index out of bounds"}
+		}
+		if idx != m.active {
+			m.active = idx
+			m.switches++
+			go m.log("i", "stratum", "Connected to "+poolHost(m.pools[idx]))
+		}
+		return true, nil
+	case "miner_pausegpu":
+		idx, ok := intParam("index")
+		var pause bool
+		if !ok || idx < 0 || idx >= len(m.devices) || json.Unmarshal(req.Params["pause"], &pause) != nil {
+			return nil, &rpcError{Code: -422, Message: "Index out of bounds"}
+		}
+		m.devices[idx].Paused = pause
+		return true, nil
+	case "miner_setverbosity":
+		v, ok := intParam("verbosity")
+		if !ok || v < minVerbosity || v > maxVerbosity {
+			return nil, &rpcError{Code: -422, Message: "Verbosity out of bounds"}
+		}
+		return true, nil
+	case "miner_restart":
+		m.dagReady = time.Now().Add(simDAGTime)
+		go m.log("i", "main", "Restarting miners...")
+		return true, nil
+	case "miner_reboot":
+		// No reboot script next to the simulator.
+		return false, nil
+	}
+	return nil, &rpcError{Code: rpcMethodNotFound, Message: "Method not found"}
+}
+
+// stat renders the simulated rig as a Stat. Caller must hold m.mu.
+func (m *simMiner) stat() Stat {
+	mode := "OpenCL"
+	if m.cuda {
+		mode = "CUDA"
+	}
+	st := Stat{
+		Version:      "ethminer-" + simulatorVersion,
+		UptimeMin:    int(time.Since(m.start).Minutes()),
+		PoolSwitches: m.switches,
+		Pool:         m.pools[m.active],
+		Detail:       true,
+		Connected:    true,
+		Epoch:        42,
+		Difficulty:   4e9,
+	}
+	for i, d := range m.devices {
+		ds := DeviceStat{
+			Index:    i,
+			Name:     d.Name,
+			PCI:      d.PCI,
+			Mode:     mode,
+			KHs:      int64(d.KHs),
+			Accepted: d.Accepted,
+			Rejected: d.Rejected,
+			Temp:     int(math.Round(d.Temp)),
+			Fan:      d.Fan,
+			PowerW:   math.Round(d.KHs / 250),
+			Paused:   d.Paused,
+		}
+		if d.Paused {
+			ds.PauseReason = "api"
+		}
+		st.TotalKHs += ds.KHs
+		st.Accepted += ds.Accepted
+		st.Rejected += ds.Rejected
+		st.Devices = append(st.Devices, ds)
+		st.PerGPU_KHs = append(st.PerGPU_KHs, ds.KHs)
+		st.Temps = append(st.Temps, ds.Temp)
+		st.Fans = append(st.Fans, ds.Fan)
+	}
+	return st
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"image"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/theme"
+)
+
+// sparkline is a tiny axis-less line chart of the last few values.
+type sparkline struct {
+	raster    *canvas.Raster
+	maxPoints int
+
+	mu     sync.Mutex
+	points []float64
+}
+
+func newSparkline(maxPoints int) *sparkline {
+	s := &sparkline{maxPoints: maxPoints}
+	s.raster = canvas.NewRaster(s.render)
+	s.raster.SetMinSize(fyne.NewSize(80, 20))
+	return s
+}
+
+func (s *sparkline) Object() fyne.CanvasObject {
+	return s.raster
+}
+
+func (s *sparkline) Add(v float64) {
+	s.mu.Lock()
+	s.points = append(s.points, v)
+	if len(s.points) > s.maxPoints {
+		s.points = s.points[len(s.points)-s.maxPoints:]
+	}
+	s.mu.Unlock()
+	s.raster.Refresh()
+}
+
+// Average returns the mean of the stored values, or false when there are
+// fewer than minPoints of them.
+func (s *sparkline) Average(minPoints int) (float64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.points) == 0 || len(s.points) < minPoints {
+		return 0, false
+	}
+	var sum float64
+	for _, v := range s.points {
+		sum += v
+	}
+	return sum / float64(len(s.points)), true
+}
+
+func (s *sparkline) render(w, h int) image.Image {
+	if w < 2 || h < 2 {
+		return image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+
+	s.mu.Lock()
+	data := append([]float64(nil), s.points...)
+	s.mu.Unlock()
+	if len(data) < 2 {
+		return img
+	}
+
+	lo, hi := data[0], data[0]
+	for _, v := range data {
+		lo = min(lo, v)
+		hi = max(hi, v)
+	}
+	if hi == lo {
+		hi = lo + 1
+	}
+	line := toNRGBA(theme.Color(theme.ColorNamePrimary))
+	xAt := func(i int) int { return i * (w - 2) / (s.maxPoints - 1) }
+	yAt := func(v float64) int { return 1 + int(float64(h-3)*(1-(v-lo)/(hi-lo))) }
+	// Right-align so a short history grows in from the right like the main chart.
+	off := s.maxPoints - len(data)
+	for i := 1; i < len(data); i++ {
+		drawLine(img, xAt(off+i-1), yAt(data[i-1]), xAt(off+i), yAt(data[i]), line)
+	}
+	return img
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// DeviceStat is the per-GPU view of a Stat. Fields that miner_getstat1 does
+// not report (name, shares, pause state) stay zero for older builds.
+type DeviceStat struct {
+	Index       int
+	Name        string
+	PCI         string
+	Mode        string
+	KHs         int64
+	Accepted    int64
+	Rejected    int64
+	Failed      int64
+	Temp        int
+	Fan         int
+	PowerW      float64
+	Paused      bool
+	PauseReason string
+}
+
+// statDetail mirrors the miner_getstatdetail result of ethminer 0.19+.
+type statDetail struct {
+	Connection struct {
+		Connected bool   `json:"connected"`
+		Switches  int64  `json:"switches"`
+		URI       string `json:"uri"`
+	} `json:"connection"`
+	Devices []struct {
+		Index    int    `json:"_index"`
+		Mode     string `json:"_mode"`
+		Hardware struct {
+			Name    string    `json:"name"`
+			PCI     string    `json:"pci"`
+			Sensors []float64 `json:"sensors"`
+		} `json:"hardware"`
+		Mining struct {
+			Hashrate    string  `json:"hashrate"`
+			Paused      bool    `json:"paused"`
+			PauseReason *string `json:"pause_reason"`
+			Shares      []int64 `json:"shares"`
+		} `json:"mining"`
+	} `json:"devices"`
+	Host struct {
+		Runtime int64  `json:"runtime"`
+		Version string `json:"version"`
+	} `json:"host"`
+	Mining struct {
+		Difficulty float64 `json:"difficulty"`
+		Epoch      int     `json:"epoch"`
+		Hashrate   string  `json:"hashrate"`
+		Shares     []int64 `json:"shares"`
+	} `json:"mining"`
+}
+
+// parseHexHashrate converts ethminer's "0x..." H/s value to kH/s.
+func parseHexHashrate(s string) int64 {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "0x")
+	if s == "" {
+		return 0
+	}
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(v / 1000)
+}
+
+func shareAt(shares []int64, i int) int64 {
+	if i < len(shares) {
+		return shares[i]
+	}
+	return 0
+}
+
+func (c *apiClient) getStatDetail() (Stat, error) {
+	result, err := c.Call("miner_getstatdetail", nil)
+	if err != nil {
+		return Stat{}, err
+	}
+	var d statDetail
+	if err := json.Unmarshal(result, &d); err != nil {
+		return Stat{}, err
+	}
+	return d.toStat(), nil
+}
+
+func (d statDetail) toStat() Stat {
+	st := Stat{
+		Version:      d.Host.Version,
+		UptimeMin:    int(d.Host.Runtime / 60),
+		TotalKHs:     parseHexHashrate(d.Mining.Hashrate),
+		Accepted:     shareAt(d.Mining.Shares, 0),
+		Rejected:     shareAt(d.Mining.Shares, 1),
+		Invalid:      shareAt(d.Mining.Shares, 2),
+		PoolSwitches: d.Connection.Switches,
+		Pool:         d.Connection.URI,
+		Detail:       true,
+		Connected:    d.Connection.Connected,
+		Epoch:        d.Mining.Epoch,
+		Difficulty:   d.Mining.Difficulty,
+	}
+	for _, dev := range d.Devices {
+		ds := DeviceStat{
+			Index:    dev.Index,
+			Name:     dev.Hardware.Name,
+			PCI:      dev.Hardware.PCI,
+			Mode:     dev.Mode,
+			KHs:      parseHexHashrate(dev.Mining.Hashrate),
+			Accepted: shareAt(dev.Mining.Shares, 0),
+			Rejected: shareAt(dev.Mining.Shares, 1),
+			Failed:   shareAt(dev.Mining.Shares, 2),
+			Paused:   dev.Mining.Paused,
+		}
+		// sensors: [temperature, fan %, power W]
+		if len(dev.Hardware.Sensors) > 0 {
+			ds.Temp = int(dev.Hardware.Sensors[0])
+		}
+		if len(dev.Hardware.Sensors) > 1 {
+			ds.Fan = int(dev.Hardware.Sensors[1])
+		}
+		if len(dev.Hardware.Sensors) > 2 {
+			ds.PowerW = dev.Hardware.Sensors[2]
+		}
+		if dev.Mining.PauseReason != nil {
+			ds.PauseReason = *dev.Mining.PauseReason
+		}
+		st.Devices = append(st.Devices, ds)
+		st.PerGPU_KHs = append(st.PerGPU_KHs, ds.KHs)
+		st.Temps = append(st.Temps, ds.Temp)
+		st.Fans = append(st.Fans, ds.Fan)
+	}
+	return st
+}
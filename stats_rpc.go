@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits for LAN peers polling this rig. Farm views poll every few seconds
+// with one short request per line.
+const (
+	statsRPCIdleTimeout = 30 * time.Second
+	statsRPCMaxLine     = 4 << 10
+	statsRPCMaxConns    = 16
+)
+
+// sharedPool cuts a pool URI down to scheme and host for peers: the user
+// part carries the wallet, the worker and sometimes a password.
+func sharedPool(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Host != "" {
+		return u.Scheme + "://" + u.Host
+	}
+	// Not a URI; still drop anything that names a user.
+	if i := strings.LastIndex(uri, "@"); i >= 0 {
+		uri = uri[i+1:]
+	}
+	return uri
+}
+
+// encodeHashrate renders kH/s as ethminer's hex H/s string.
+func encodeHashrate(khs int64) string {
+	return fmt.Sprintf("0x%08x", khs*1000)
+}
+
+// encodeStat1 renders st as a miner_getstat1 result, the inverse of
+// getStat1.
+func encodeStat1(st Stat) []string {
+	var per, temps, dual []string
+	for i, kh := range st.PerGPU_KHs {
+		per = append(per, strconv.FormatInt(kh, 10))
+		t, f := 0, 0
+		if i < len(st.Temps) {
+			t = st.Temps[i]
+		}
+		if i < len(st.Fans) {
+			f = st.Fans[i]
+		}
+		temps = append(temps, strconv.Itoa(t), strconv.Itoa(f))
+		dual = append(dual, "off")
+	}
+	return []string{
+		st.Version,
+		strconv.Itoa(st.UptimeMin),
+		fmt.Sprintf("%d;%d;%d", st.TotalKHs, st.Accepted, st.Rejected),
+		strings.Join(per, ";"),
+		"0;0;0",
+		strings.Join(dual, ";"),
+		strings.Join(temps, ";"),
+		poolHost(st.Pool),
+		fmt.Sprintf("%d;%d;0;0", st.Invalid, st.PoolSwitches),
+	}
+}
+
+// encodeStatDetail renders st as a miner_getstatdetail result, the inverse
+// of statDetail.toStat.
+func encodeStatDetail(st Stat) map[string]any {
+	devices := []map[string]any{}
+	for _, d := range st.Devices {
+		var reason any
+		if d.PauseReason != "" {
+			reason = d.PauseReason
+		}
+		devices = append(devices, map[string]any{
+			"_index": d.Index,
+			"_mode":  d.Mode,
+			"hardware": map[string]any{
+				"name":    d.Name,
+				"pci":     d.PCI,
+				"sensors": []float64{float64(d.Temp), float64(d.Fan), d.PowerW},
+				"type":    "GPU",
+			},
+			"mining": map[string]any{
+				"hashrate":     encodeHashrate(d.KHs),
+				"paused":       d.Paused,
+				"pause_reason": reason,
+				"shares":       []int64{d.Accepted, d.Rejected, d.Failed, 0},
+			},
+		})
+	}
+	return map[string]any{
+		"connection": map[string]any{
+			"connected": st.Connected,
+			"switches":  st.PoolSwitches,
+			"uri":       st.Pool,
+		},
+		"devices": devices,
+		"host": map[string]any{
+			"runtime": st.UptimeMin * 60,
+			"version": st.Version,
+		},
+		"mining": map[string]any{
+			"difficulty": st.Difficulty,
+			"epoch":      st.Epoch,
+			"hashrate":   encodeHashrate(st.TotalKHs),
+			"shares":     []int64{st.Accepted, st.Rejected, st.Invalid, 0},
+		},
+	}
+}
+
+// serveStatsRPC answers the read-only subset of the ethminer API
+// (miner_ping, miner_getstat1, miner_getstatdetail) from stat, so the farm
+// view of another rig can poll this one. The pool is shown without its user
+// part. Connections beyond statsRPCMaxConns are refused, and a connection
+// is closed after statsRPCIdleTimeout without a request or on a request line
+// longer than statsRPCMaxLine. It returns when ln is closed.
+func serveStatsRPC(ln net.Listener, stat func() (Stat, bool)) {
+	slots := make(chan struct{}, statsRPCMaxConns)
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		select {
+		case slots <- struct{}{}:
+		default:
+			conn.Close()
+			continue
+		}
+		go func(conn net.Conn) {
+			defer func() { <-slots }()
+			defer conn.Close()
+			r := bufio.NewReaderSize(conn, statsRPCMaxLine)
+			for {
+				conn.SetDeadline(time.Now().Add(statsRPCIdleTimeout))
+				// ReadSlice fails with bufio.ErrBufferFull on an overlong line.
+				line, err := r.ReadSlice('\n')
+				if err != nil {
+					return
+				}
+				var req struct {
+					ID     json.RawMessage `json:"id"`
+					Method string          `json:"method"`
+				}
+				resp := map[string]any{"jsonrpc": "2.0"}
+				if err := json.Unmarshal(line, &req); err != nil {
+					resp["error"] = &rpcError{Code: -32700, Message: "Parse error"}
+				} else {
+					resp["id"] = req.ID
+					st, ok := stat()
+					st.Pool = sharedPool(st.Pool)
+					switch {
+					case req.Method == "miner_ping":
+						resp["result"] = "pong"
+					case req.Method != "miner_getstat1" && req.Method != "miner_getstatdetail":
+						resp["error"] = &rpcError{Code: rpcMethodNotFound, Message: "Method not found"}
+					case req.Method == "miner_getstatdetail" && ok && len(st.Devices) < len(st.PerGPU_KHs):
+						// The local miner has no detail API; peers fall back
+						// to getstat1 as they would against it directly.
+						resp["error"] = &rpcError{Code: rpcMethodNotFound, Message: "Method not found"}
+					case !ok:
+						resp["error"] = &rpcError{Code: -32000, Message: "miner is not running"}
+					case req.Method == "miner_getstat1":
+						resp["result"] = encodeStat1(st)
+					default:
+						resp["result"] = encodeStatDetail(st)
+					}
+				}
+				b, _ := json.Marshal(resp)
+				if _, err := conn.Write(append(b, '\n')); err != nil {
+					return
+				}
+			}
+		}(conn)
+	}
+}
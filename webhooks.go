@@ -0,0 +1,282 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	webhookJSON    = "json"
+	webhookDiscord = "discord"
+	webhookSlack   = "slack"
+
+	webhookTimeout   = 10 * time.Second
+	webhookAttempts  = 4
+	webhookBackoff   = 2 * time.Second
+	webhookMaxWait   = time.Minute
+	webhookQueueSize = 64
+	// webhookMinInterval is the shortest gap between two posts of the same
+	// alert kind to the same hook; alerts in between are counted and
+	// mentioned in the next post.
+	webhookMinInterval = time.Minute
+)
+
+var webhookFormats = []string{webhookJSON, webhookDiscord, webhookSlack}
+
+// WebhookConfig is one outgoing webhook. Every alert is posted to every hook.
+type WebhookConfig struct {
+	URL    string `json:"url"`
+	Format string `json:"format"`
+}
+
+func (h WebhookConfig) Validate() error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q (http:// or https://)", h.URL)
+	}
+	switch h.Format {
+	case webhookJSON, webhookDiscord, webhookSlack:
+		return nil
+	default:
+		return fmt.Errorf("unknown webhook format %q", h.Format)
+	}
+}
+
+func webhookFormatName(format string) string {
+	switch format {
+	case webhookDiscord:
+		return "Discord"
+	case webhookSlack:
+		return "Slack"
+	default:
+		return "JSON"
+	}
+}
+
+// webhookPayload renders ev for the hook's format. suppressed is the number
+// of same-kind alerts dropped by rate limiting since the previous post.
+func webhookPayload(format, rig string, ev alertEvent, suppressed int) ([]byte, error) {
+	msg := ev.Message
+	if suppressed > 0 {
+		msg += fmt.Sprintf(" (%d similar alert(s) suppressed)", suppressed)
+	}
+	switch format {
+	case webhookDiscord:
+		return json.Marshal(map[string]any{
+			"username": "Olivetum Miner",
+			"content":  fmt.Sprintf("**%s**: %s", rig, msg),
+		})
+	case webhookSlack:
+		return json.Marshal(map[string]any{
+			"text": fmt.Sprintf("*%s*: %s", rig, msg),
+		})
+	default:
+		p := map[string]any{
+			"event":   ev.Kind,
+			"time":    ev.Time.UTC().Format(time.RFC3339),
+			"rig":     rig,
+			"message": msg,
+		}
+		if len(ev.Details) > 0 {
+			p["details"] = ev.Details
+		}
+		if suppressed > 0 {
+			p["suppressed"] = suppressed
+		}
+		return json.Marshal(p)
+	}
+}
+
+type webhookJob struct {
+	hook WebhookConfig
+	kind string
+	body []byte
+}
+
+// webhookNotifier posts alerts to the configured hooks, retrying failed posts
+// with exponential backoff. Each hook has its own worker, so a hook that is
+// down does not hold up the others.
+type webhookNotifier struct {
+	client  *http.Client
+	backoff time.Duration
+	now     func() time.Time
+	sleep   func(time.Duration)
+	logf    func(format string, args ...any)
+	rig     func() string
+
+	mu         sync.Mutex
+	hooks      []WebhookConfig
+	last       map[string]time.Time
+	suppressed map[string]int
+	queues     map[WebhookConfig]chan webhookJob
+}
+
+// newWebhookNotifier returns a notifier with no hooks; rig names this rig in
+// messages. Workers start with the first alert for each hook.
+func newWebhookNotifier(rig func() string, logf func(format string, args ...any)) *webhookNotifier {
+	return &webhookNotifier{
+		rig:        rig,
+		client:     &http.Client{Timeout: webhookTimeout},
+		backoff:    webhookBackoff,
+		now:        time.Now,
+		sleep:      time.Sleep,
+		logf:       logf,
+		last:       map[string]time.Time{},
+		suppressed: map[string]int{},
+		queues:     map[WebhookConfig]chan webhookJob{},
+	}
+}
+
+// Configure replaces the hooks alerts are posted to. Workers of removed hooks
+// finish what they have queued and exit.
+func (n *webhookNotifier) Configure(hooks []WebhookConfig) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.hooks = append([]WebhookConfig(nil), hooks...)
+	for h, q := range n.queues {
+		if !slices.Contains(n.hooks, h) {
+			close(q)
+			delete(n.queues, h)
+		}
+	}
+}
+
+// Notify queues ev for every hook, subject to rate limiting. It never blocks.
+func (n *webhookNotifier) Notify(ev alertEvent) {
+	rig := n.rig()
+	n.mu.Lock()
+	var dropped []webhookJob
+	now := n.now()
+	for _, h := range n.hooks {
+		key := h.URL + "\x00" + ev.Kind
+		if last, ok := n.last[key]; ok && now.Sub(last) < webhookMinInterval {
+			n.suppressed[key]++
+			continue
+		}
+		body, err := webhookPayload(h.Format, rig, ev, n.suppressed[key])
+		if err != nil {
+			continue
+		}
+		n.last[key] = now
+		delete(n.suppressed, key)
+		job := webhookJob{hook: h, kind: ev.Kind, body: body}
+		q, ok := n.queues[h]
+		if !ok {
+			q = make(chan webhookJob, webhookQueueSize)
+			n.queues[h] = q
+			go n.run(q)
+		}
+		select {
+		case q <- job:
+		default:
+			dropped = append(dropped, job)
+		}
+	}
+	n.mu.Unlock()
+
+	for _, job := range dropped {
+		n.logf("[webhook] queue full, dropped %s for %s\n", job.kind, redactURL(job.hook.URL))
+	}
+}
+
+// Test posts a test alert to h right away, without retries, so the settings
+// dialog can show the outcome.
+func (n *webhookNotifier) Test(ctx context.Context, h WebhookConfig) error {
+	if err := h.Validate(); err != nil {
+		return err
+	}
+	ev := alertEvent{Kind: alertTest, Time: n.now(), Message: "Test alert from Olivetum Miner"}
+	body, err := webhookPayload(h.Format, n.rig(), ev, 0)
+	if err != nil {
+		return err
+	}
+	_, _, err = n.post(ctx, h.URL, body)
+	return err
+}
+
+func (n *webhookNotifier) run(queue <-chan webhookJob) {
+	for job := range queue {
+		if err := n.deliver(job); err != nil {
+			n.logf("[webhook] %s to %s failed: %v\n", job.kind, redactURL(job.hook.URL), err)
+		}
+	}
+}
+
+func (n *webhookNotifier) deliver(job webhookJob) error {
+	wait := n.backoff
+	var err error
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		var retry bool
+		var after time.Duration
+		retry, after, err = n.post(context.Background(), job.hook.URL, job.body)
+		if err == nil || !retry || attempt == webhookAttempts {
+			break
+		}
+		if after > 0 {
+			wait = min(after, webhookMaxWait)
+		}
+		n.sleep(wait)
+		wait = min(wait*2, webhookMaxWait)
+	}
+	return err
+}
+
+// post sends body once. Network errors, 429 and 5xx responses are worth
+// retrying; a 429 may say how long to wait.
+func (n *webhookNotifier) post(ctx context.Context, target string, body []byte) (retry bool, after time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return false, 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "olivetum-miner-gui")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// The error text repeats the URL, which may embed a token.
+		return true, 0, fmt.Errorf("request failed: %v", unwrapURLError(err))
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, 0, nil
+	}
+	err = fmt.Errorf("HTTP %s", resp.Status)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if secs, perr := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); perr == nil && secs > 0 {
+			after = time.Duration(secs) * time.Second
+		}
+		return true, after, err
+	case resp.StatusCode >= 500:
+		return true, 0, err
+	default:
+		return false, 0, err
+	}
+}
+
+func unwrapURLError(err error) error {
+	if ue, ok := err.(*url.Error); ok {
+		return ue.Err
+	}
+	return err
+}
+
+// redactURL keeps only the scheme and host: Discord and Slack webhook paths
+// are secrets.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "webhook"
+	}
+	return u.Scheme + "://" + u.Host
+}
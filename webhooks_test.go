@@ -0,0 +1,234 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func testNotifier(t *testing.T) *webhookNotifier {
+	t.Helper()
+	// Workers may still log after the test returns, so not through t.
+	n := newWebhookNotifier(func() string { return "rig1" }, func(string, ...any) {})
+	n.sleep = func(time.Duration) {}
+	return n
+}
+
+// collect returns a server that records request bodies and answers with the
+// given statuses in turn (200 once they run out).
+func collect(t *testing.T, statuses ...int) (*httptest.Server, <-chan map[string]any) {
+	t.Helper()
+	bodies := make(chan map[string]any, 16)
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		var m map[string]any
+		if err := json.Unmarshal(b, &m); err != nil {
+			t.Errorf("body is not JSON: %q", b)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q", ct)
+		}
+		bodies <- m
+		mu.Lock()
+		status := http.StatusOK
+		if len(statuses) > 0 {
+			status, statuses = statuses[0], statuses[1:]
+		}
+		mu.Unlock()
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "7")
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, bodies
+}
+
+func next(t *testing.T, bodies <-chan map[string]any) map[string]any {
+	t.Helper()
+	select {
+	case m := <-bodies:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for a webhook post")
+		return nil
+	}
+}
+
+func TestWebhookPayloadFormats(t *testing.T) {
+	ev := alertEvent{
+		Kind:    alertCrash,
+		Time:    time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message: "Miner crashed",
+		Details: map[string]any{"exitCode": 2},
+	}
+	tests := []struct {
+		format     string
+		suppressed int
+		want       map[string]any
+	}{
+		{webhookDiscord, 0, map[string]any{
+			"username": "Olivetum Miner",
+			"content":  "**rig1**: Miner crashed",
+		}},
+		{webhookSlack, 0, map[string]any{
+			"text": "*rig1*: Miner crashed",
+		}},
+		{webhookJSON, 0, map[string]any{
+			"event":   "miner_crashed",
+			"time":    "2024-01-02T03:04:05Z",
+			"rig":     "rig1",
+			"message": "Miner crashed",
+			"details": map[string]any{"exitCode": float64(2)},
+		}},
+		{webhookSlack, 3, map[string]any{
+			"text": "*rig1*: Miner crashed (3 similar alert(s) suppressed)",
+		}},
+		{webhookJSON, 3, map[string]any{
+			"event":      "miner_crashed",
+			"time":       "2024-01-02T03:04:05Z",
+			"rig":        "rig1",
+			"message":    "Miner crashed (3 similar alert(s) suppressed)",
+			"details":    map[string]any{"exitCode": float64(2)},
+			"suppressed": float64(3),
+		}},
+	}
+	for _, tt := range tests {
+		b, err := webhookPayload(tt.format, "rig1", ev, tt.suppressed)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		var got map[string]any
+		if err := json.Unmarshal(b, &got); err != nil {
+			t.Fatalf("%s: %v", tt.format, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s (suppressed %d) = %v, want %v", tt.format, tt.suppressed, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookDeliverRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantPosts int
+		wantWaits []time.Duration
+		wantErr   bool
+	}{
+		{"ok", nil, 1, nil, false},
+		{"5xx then ok", []int{503, 500}, 3, []time.Duration{2 * time.Second, 4 * time.Second}, false},
+		{"429 honours Retry-After", []int{429}, 2, []time.Duration{7 * time.Second}, false},
+		{"4xx is final", []int{400}, 1, nil, true},
+		{"404 is final", []int{404}, 1, nil, true},
+		{"gives up", []int{502, 502, 502, 502}, webhookAttempts, []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second}, true},
+	}
+	for _, tt := range tests {
+		srv, bodies := collect(t, tt.statuses...)
+		n := testNotifier(t)
+		var waits []time.Duration
+		n.sleep = func(d time.Duration) { waits = append(waits, d) }
+		job := webhookJob{hook: WebhookConfig{URL: srv.URL, Format: webhookJSON}, kind: alertCrash, body: []byte(`{}`)}
+		err := n.deliver(job)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if got := len(bodies); got != tt.wantPosts {
+			t.Errorf("%s: %d posts, want %d", tt.name, got, tt.wantPosts)
+		}
+		if !reflect.DeepEqual(waits, tt.wantWaits) {
+			t.Errorf("%s: waits = %v, want %v", tt.name, waits, tt.wantWaits)
+		}
+	}
+}
+
+func TestWebhookRateLimit(t *testing.T) {
+	srv, bodies := collect(t)
+	n := testNotifier(t)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	n.now = func() time.Time { return now }
+	n.Configure([]WebhookConfig{{URL: srv.URL, Format: webhookJSON}})
+
+	crash := alertEvent{Kind: alertCrash, Message: "crashed"}
+	temp := alertEvent{Kind: alertTemperature, Message: "hot"}
+
+	n.Notify(crash)
+	if m := next(t, bodies); m["event"] != alertCrash || m["suppressed"] != nil {
+		t.Errorf("first post = %v", m)
+	}
+
+	// Within the interval the same kind is counted, other kinds still go out.
+	now = now.Add(10 * time.Second)
+	n.Notify(crash)
+	n.Notify(crash)
+	n.Notify(temp)
+	if m := next(t, bodies); m["event"] != alertTemperature {
+		t.Errorf("post during interval = %v, want the temperature alert", m)
+	}
+
+	now = now.Add(webhookMinInterval)
+	n.Notify(crash)
+	m := next(t, bodies)
+	if m["event"] != alertCrash || m["suppressed"] != float64(2) || m["message"] != "crashed (2 similar alert(s) suppressed)" {
+		t.Errorf("post after interval = %v, want crash with 2 suppressed", m)
+	}
+
+	// The counter starts over after it has been reported.
+	now = now.Add(webhookMinInterval)
+	n.Notify(crash)
+	if m := next(t, bodies); m["suppressed"] != nil {
+		t.Errorf("next post = %v, want no suppressed count", m)
+	}
+	select {
+	case m := <-bodies:
+		t.Errorf("unexpected post %v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWebhookHooksDoNotBlockEachOther(t *testing.T) {
+	down, _ := collect(t, 503, 503, 503, 503)
+	up, upBodies := collect(t)
+	n := testNotifier(t)
+	release := make(chan struct{})
+	n.sleep = func(time.Duration) { <-release }
+	defer close(release)
+	n.Configure([]WebhookConfig{
+		{URL: down.URL, Format: webhookJSON},
+		{URL: up.URL, Format: webhookSlack},
+	})
+
+	// The first hook is stuck in its retry backoff until release is closed.
+	n.Notify(alertEvent{Kind: alertCrash, Message: "crashed"})
+	if m := next(t, upBodies); !strings.Contains(m["text"].(string), "crashed") {
+		t.Errorf("post to healthy hook = %v", m)
+	}
+}
+
+func TestWebhookConfigureStopsRemovedWorkers(t *testing.T) {
+	a, aBodies := collect(t)
+	b, bBodies := collect(t)
+	n := testNotifier(t)
+	hookA := WebhookConfig{URL: a.URL, Format: webhookJSON}
+	hookB := WebhookConfig{URL: b.URL, Format: webhookJSON}
+	n.Configure([]WebhookConfig{hookA, hookB})
+	n.Notify(alertEvent{Kind: alertCrash, Message: "crashed"})
+	next(t, aBodies)
+	next(t, bBodies)
+
+	n.Configure([]WebhookConfig{hookB})
+	n.mu.Lock()
+	_, hasA := n.queues[hookA]
+	_, hasB := n.queues[hookB]
+	n.mu.Unlock()
+	if hasA || !hasB {
+		t.Errorf("queues after removing a hook: A %v, B %v; want only B", hasA, hasB)
+	}
+}